@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+)
+
+// runCostReport prints the cost of all provider resources in the Hetzner project.
+// Usage: daytona-provider-hetzner cost-report [-format json|csv] [-target-options '{...}']
+func runCostReport(args []string) error {
+	flags := flag.NewFlagSet("cost-report", flag.ContinueOnError)
+	format := flags.String("format", "json", "Output format, json or csv")
+	targetOptionsJson := flags.String("target-options", "{}", "Target options JSON used to authenticate with Hetzner")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	targetOptions, err := types.ParseTargetOptions(*targetOptionsJson)
+	if err != nil {
+		return err
+	}
+	for _, warning := range targetOptions.Warnings {
+		fmt.Fprintln(os.Stderr, "Warning: "+warning)
+	}
+
+	report, err := hetznerutil.GetCostReport(targetOptions)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		return report.WriteJSON(os.Stdout)
+	case "csv":
+		return report.WriteCSV(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported format %s", *format)
+	}
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimitedLineWriter writes complete lines to the underlying writer with a prefix. Lines above the
+// rate limit are dropped and the number of dropped lines is reported once the next window starts.
+type RateLimitedLineWriter struct {
+	writer            io.Writer
+	prefix            string
+	maxLinesPerSecond int
+
+	mu          sync.Mutex
+	buffer      []byte
+	windowStart time.Time
+	count       int
+	dropped     int
+	now         func() time.Time
+}
+
+func NewRateLimitedLineWriter(writer io.Writer, prefix string, maxLinesPerSecond int) *RateLimitedLineWriter {
+	return &RateLimitedLineWriter{
+		writer:            writer,
+		prefix:            prefix,
+		maxLinesPerSecond: maxLinesPerSecond,
+		now:               time.Now,
+	}
+}
+
+func (w *RateLimitedLineWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := string(w.buffer[:i])
+		w.buffer = w.buffer[i+1:]
+		w.writeLine(line)
+	}
+
+	return len(p), nil
+}
+
+// Flush writes a buffered incomplete line and reports lines dropped in the current window.
+func (w *RateLimitedLineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buffer) > 0 {
+		w.writeLine(string(w.buffer))
+		w.buffer = nil
+	}
+	w.reportDropped()
+}
+
+func (w *RateLimitedLineWriter) writeLine(line string) {
+	now := w.now()
+	if now.Sub(w.windowStart) >= time.Second {
+		w.reportDropped()
+		w.windowStart = now
+		w.count = 0
+	}
+
+	if w.maxLinesPerSecond > 0 && w.count >= w.maxLinesPerSecond {
+		w.dropped++
+		return
+	}
+
+	w.count++
+	w.writer.Write([]byte(w.prefix + line + "\n"))
+}
+
+func (w *RateLimitedLineWriter) reportDropped() {
+	if w.dropped == 0 {
+		return
+	}
+
+	w.writer.Write([]byte(fmt.Sprintf("%s... %d lines dropped\n", w.prefix, w.dropped)))
+	w.dropped = 0
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedLineWriter(t *testing.T) {
+	var output bytes.Buffer
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	writer := NewRateLimitedLineWriter(&output, "[agent] ", 2)
+	writer.now = func() time.Time { return now }
+
+	writer.Write([]byte("first\nsec"))
+	writer.Write([]byte("ond\nthird\nfourth\n"))
+
+	now = now.Add(time.Second)
+	writer.Write([]byte("fifth\nincomplete"))
+	writer.Flush()
+
+	expected := "[agent] first\n" +
+		"[agent] second\n" +
+		"[agent] ... 2 lines dropped\n" +
+		"[agent] fifth\n" +
+		"[agent] incomplete\n"
+	if output.String() != expected {
+		t.Fatalf("Expected output %q, got %q", expected, output.String())
+	}
+}
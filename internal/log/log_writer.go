@@ -1,41 +1,96 @@
 package log
 
 import (
-	"fmt"
+	"bytes"
 	"io"
-	"time"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/go-hclog"
 )
 
-type DebugLogWriter struct{}
+// LogLevelEnvVar sets the level of the provider logger, e.g. trace, debug, info, warn or error.
+const LogLevelEnvVar = "HETZNER_PROVIDER_LOG_LEVEL"
 
-func (w *DebugLogWriter) Write(p []byte) (n int, err error) {
-	log.Debug(string(p))
-	return len(p), nil
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// LevelFromEnv returns the log level set in the environment, falling back to info.
+func LevelFromEnv() hclog.Level {
+	level := hclog.LevelFromString(os.Getenv(LogLevelEnvVar))
+	if level == hclog.NoLevel {
+		return hclog.Info
+	}
+	return level
 }
 
-type InfoLogWriter struct{}
+// NewLogger returns the JSON logger shared by go-plugin and the provider.
+func NewLogger(output io.Writer) hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:       "hetzner-provider",
+		Level:      LevelFromEnv(),
+		Output:     output,
+		JSONFormat: true,
+	})
+}
 
-func (w *InfoLogWriter) Write(p []byte) (n int, err error) {
-	log.Info(string(p))
-	return len(p), nil
+// LogWriter turns the output written to a workspace or project log into one log entry per line. Terminal
+// control sequences and spinner frames are stripped so that only the final text of each line is logged.
+type LogWriter struct {
+	logger hclog.Logger
+	level  hclog.Level
+
+	mu     sync.Mutex
+	buffer []byte
 }
 
-func ShowSpinner(logWriter io.Writer, startStatement, endStatement string) chan struct{} {
-	stopSpinnerChan := make(chan struct{})
-	go func() {
-		spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-		for i := 0; ; i++ {
-			select {
-			case <-stopSpinnerChan:
-				logWriter.Write([]byte("\r\033[K"))
-				logWriter.Write([]byte(endStatement + "\n"))
-				return
-			case <-time.After(200 * time.Millisecond):
-				logWriter.Write([]byte(fmt.Sprintf("%s %s\r", spinner[i%len(spinner)], startStatement)))
-			}
+func NewLogWriter(logger hclog.Logger, level hclog.Level) *LogWriter {
+	return &LogWriter{
+		logger: logger,
+		level:  level,
+	}
+}
+
+func (w *LogWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
 		}
-	}()
-	return stopSpinnerChan
+
+		line := string(w.buffer[:i])
+		w.buffer = w.buffer[i+1:]
+		w.writeLine(line)
+	}
+
+	return len(p), nil
+}
+
+// Flush logs the remaining partial line, if any.
+func (w *LogWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buffer) > 0 {
+		w.writeLine(string(w.buffer))
+		w.buffer = nil
+	}
+}
+
+func (w *LogWriter) writeLine(line string) {
+	line = ansiEscape.ReplaceAllString(line, "")
+	if i := strings.LastIndexByte(strings.TrimRight(line, "\r"), '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	w.logger.Log(w.level, line)
 }
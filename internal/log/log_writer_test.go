@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestLogWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		flush  bool
+		want   []string
+	}{
+		{
+			name:   "one entry per line",
+			writes: []string{"first\nsecond\n"},
+			want:   []string{"first", "second"},
+		},
+		{
+			name:   "line split across writes",
+			writes: []string{"Creating Hetz", "ner volume...\n"},
+			want:   []string{"Creating Hetzner volume..."},
+		},
+		{
+			name:   "spinner frames and escape codes",
+			writes: []string{"⠋ Waiting\r", "⠙ Waiting\r", "\r\033[K", "Agent started\n"},
+			want:   []string{"Agent started"},
+		},
+		{
+			name:   "empty lines are skipped",
+			writes: []string{"\n\033[?25h\n\n"},
+			want:   nil,
+		},
+		{
+			name:   "partial line is logged on flush",
+			writes: []string{"done\npartial"},
+			flush:  true,
+			want:   []string{"done", "partial"},
+		},
+		{
+			name:   "partial line is kept until flush",
+			writes: []string{"done\npartial"},
+			want:   []string{"done"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			logger := hclog.New(&hclog.LoggerOptions{
+				Output:     &output,
+				Level:      hclog.Info,
+				JSONFormat: true,
+			}).With("workspace_id", "ws1")
+
+			writer := NewLogWriter(logger, hclog.Info)
+			for _, write := range tt.writes {
+				n, err := writer.Write([]byte(write))
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if n != len(write) {
+					t.Errorf("expected %d bytes written, got %d", len(write), n)
+				}
+			}
+			if tt.flush {
+				writer.Flush()
+			}
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				var entry map[string]interface{}
+				err := json.Unmarshal([]byte(line), &entry)
+				if err != nil {
+					t.Fatalf("invalid log entry %q: %s", line, err)
+				}
+				if entry["workspace_id"] != "ws1" {
+					t.Errorf("expected workspace_id field in %q", line)
+				}
+				if entry["@level"] != "info" {
+					t.Errorf("expected info level in %q", line)
+				}
+				got = append(got, entry["@message"].(string))
+			}
+
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLevelFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  hclog.Level
+	}{
+		{"", hclog.Info},
+		{"debug", hclog.Debug},
+		{"TRACE", hclog.Trace},
+		{"warn", hclog.Warn},
+		{"unknown", hclog.Info},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(LogLevelEnvVar, tt.value)
+		if got := LevelFromEnv(); got != tt.want {
+			t.Errorf("LevelFromEnv() with %q = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type ProgressMode string
+
+const (
+	// ProgressModePlain writes one line per step event. It is the default since provider logs usually end up in files.
+	ProgressModePlain ProgressMode = "plain"
+	// ProgressModeInteractive animates a spinner while a step is running and is meant for terminals.
+	ProgressModeInteractive ProgressMode = "interactive"
+	// ProgressModeJSON writes one JSON object per step event.
+	ProgressModeJSON ProgressMode = "json"
+)
+
+// ProgressModeEnvVar selects the progress mode of the provider.
+const ProgressModeEnvVar = "HETZNER_PROVIDER_PROGRESS"
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// DefaultProgressMode returns the progress mode set in the environment, falling back to plain output.
+func DefaultProgressMode() ProgressMode {
+	switch mode := ProgressMode(os.Getenv(ProgressModeEnvVar)); mode {
+	case ProgressModeInteractive, ProgressModeJSON:
+		return mode
+	default:
+		return ProgressModePlain
+	}
+}
+
+// Progress reports the start, completion and failure of long running steps to a writer.
+type Progress struct {
+	writer io.Writer
+	mode   ProgressMode
+	now    func() time.Time
+}
+
+func NewProgress(writer io.Writer, mode ProgressMode) *Progress {
+	return &Progress{
+		writer: writer,
+		mode:   mode,
+		now:    time.Now,
+	}
+}
+
+// Step is a running step. Exactly one of Finish, Fail or Stop takes effect; later calls are ignored,
+// so Stop can always be deferred to make sure the spinner goroutine exits on early returns.
+type Step struct {
+	progress *Progress
+	name     string
+	started  time.Time
+
+	once        sync.Once
+	stopSpinner chan struct{}
+	spinnerDone chan struct{}
+}
+
+type progressEvent struct {
+	Step      string `json:"step"`
+	Event     string `json:"event"`
+	Message   string `json:"message,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+}
+
+// Start reports the start of a step.
+func (p *Progress) Start(name string) *Step {
+	step := &Step{
+		progress: p,
+		name:     name,
+		started:  p.now(),
+	}
+
+	switch p.mode {
+	case ProgressModeInteractive:
+		step.stopSpinner = make(chan struct{})
+		step.spinnerDone = make(chan struct{})
+		go step.spin()
+	case ProgressModeJSON:
+		p.writeEvent(progressEvent{Step: name, Event: "start"})
+	default:
+		p.writer.Write([]byte(name + "...\n"))
+	}
+
+	return step
+}
+
+// Finish reports the successful completion of the step.
+func (s *Step) Finish(message string) {
+	s.end(func(elapsed time.Duration) {
+		switch s.progress.mode {
+		case ProgressModeJSON:
+			s.progress.writeEvent(progressEvent{Step: s.name, Event: "finish", Message: message, ElapsedMs: elapsed.Milliseconds()})
+		default:
+			s.progress.writer.Write([]byte(fmt.Sprintf("%s (%s)\n", message, formatElapsed(elapsed))))
+		}
+	})
+}
+
+// Fail reports that the step failed.
+func (s *Step) Fail(err error) {
+	s.end(func(elapsed time.Duration) {
+		switch s.progress.mode {
+		case ProgressModeJSON:
+			s.progress.writeEvent(progressEvent{Step: s.name, Event: "fail", Error: err.Error(), ElapsedMs: elapsed.Milliseconds()})
+		default:
+			s.progress.writer.Write([]byte(fmt.Sprintf("%s failed after %s: %s\n", s.name, formatElapsed(elapsed), err)))
+		}
+	})
+}
+
+// Stop ends the step without reporting an outcome if it has not been finished or failed yet.
+func (s *Step) Stop() {
+	s.end(func(elapsed time.Duration) {})
+}
+
+func (s *Step) end(report func(elapsed time.Duration)) {
+	s.once.Do(func() {
+		if s.stopSpinner != nil {
+			close(s.stopSpinner)
+			<-s.spinnerDone
+			s.progress.writer.Write([]byte("\r\033[K"))
+		}
+		report(s.progress.now().Sub(s.started))
+	})
+}
+
+func (s *Step) spin() {
+	defer close(s.spinnerDone)
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-s.stopSpinner:
+			return
+		case <-ticker.C:
+			s.progress.writer.Write([]byte(fmt.Sprintf("%s %s\r", spinnerFrames[i%len(spinnerFrames)], s.name)))
+		}
+	}
+}
+
+func (p *Progress) writeEvent(event progressEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	p.writer.Write(append(data, '\n'))
+}
+
+func formatElapsed(elapsed time.Duration) string {
+	return elapsed.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProgress(output *bytes.Buffer, mode ProgressMode) *Progress {
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	progress := NewProgress(output, mode)
+	progress.now = func() time.Time {
+		now = now.Add(1500 * time.Millisecond)
+		return now
+	}
+	return progress
+}
+
+func TestProgressPlain(t *testing.T) {
+	var output bytes.Buffer
+	progress := newTestProgress(&output, ProgressModePlain)
+
+	step := progress.Start("Creating Hetzner volume")
+	step.Finish("Hetzner volume created")
+	step.Stop()
+
+	step = progress.Start("Creating Hetzner server")
+	step.Fail(errors.New("server type not found"))
+	step.Finish("Hetzner server created")
+
+	expected := "Creating Hetzner volume...\n" +
+		"Hetzner volume created (1.5s)\n" +
+		"Creating Hetzner server...\n" +
+		"Creating Hetzner server failed after 1.5s: server type not found\n"
+	if output.String() != expected {
+		t.Fatalf("Expected output %q, got %q", expected, output.String())
+	}
+}
+
+func TestProgressJSON(t *testing.T) {
+	var output bytes.Buffer
+	progress := newTestProgress(&output, ProgressModeJSON)
+
+	step := progress.Start("Creating Hetzner volume")
+	step.Finish("Hetzner volume created")
+
+	expected := `{"step":"Creating Hetzner volume","event":"start","elapsed_ms":0}` + "\n" +
+		`{"step":"Creating Hetzner volume","event":"finish","message":"Hetzner volume created","elapsed_ms":1500}` + "\n"
+	if output.String() != expected {
+		t.Fatalf("Expected output %q, got %q", expected, output.String())
+	}
+}
+
+func TestProgressInteractiveStop(t *testing.T) {
+	var output bytes.Buffer
+	progress := newTestProgress(&output, ProgressModeInteractive)
+
+	step := progress.Start("Waiting for the agent to start")
+	time.Sleep(300 * time.Millisecond)
+	step.Stop()
+
+	// The spinner goroutine has exited once Stop returns, so the output must not change anymore.
+	written := output.String()
+	time.Sleep(300 * time.Millisecond)
+	if output.String() != written {
+		t.Fatalf("Expected spinner to stop writing after Stop")
+	}
+	if !strings.Contains(written, "Waiting for the agent to start\r") {
+		t.Fatalf("Expected spinner frames in output, got %q", written)
+	}
+}
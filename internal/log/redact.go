@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces secret values in the log output.
+const RedactedValue = "[REDACTED]"
+
+// minSecretLength avoids masking every occurrence of short values such as "1" or "yes".
+const minSecretLength = 4
+
+var secretEnvVarMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSPHRASE", "API_KEY", "APIKEY", "PRIVATE_KEY", "CREDENTIAL"}
+
+// IsSecretEnvVar reports whether the value of the env var with the given name must be redacted from logs.
+func IsSecretEnvVar(name string) bool {
+	name = strings.ToUpper(name)
+	for _, marker := range secretEnvVarMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactingWriter masks registered secrets before passing the output to the underlying writer. A trailing
+// part of a write that could be the start of a secret is held back until the next write or Flush, so that
+// secrets split across writes are masked as well.
+type RedactingWriter struct {
+	writer io.Writer
+
+	mu      sync.Mutex
+	secrets [][]byte
+	pending []byte
+}
+
+func NewRedactingWriter(writer io.Writer, secrets ...string) *RedactingWriter {
+	w := &RedactingWriter{writer: writer}
+	for _, secret := range secrets {
+		w.AddSecret(secret)
+	}
+	return w
+}
+
+// AddSecret registers a value that must not appear in the output.
+func (w *RedactingWriter) AddSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, s := range w.secrets {
+		if string(s) == secret {
+			return
+		}
+	}
+	w.secrets = append(w.secrets, []byte(secret))
+
+	// Longer secrets are replaced first so that a secret containing another one is masked as a whole.
+	sort.SliceStable(w.secrets, func(i, j int) bool {
+		return len(w.secrets[i]) > len(w.secrets[j])
+	})
+}
+
+func (w *RedactingWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buffer := w.redact(append(w.pending, p...))
+	hold := w.secretPrefixLength(buffer)
+
+	w.pending = append([]byte(nil), buffer[len(buffer)-hold:]...)
+	if len(buffer) > hold {
+		_, err = w.writer.Write(buffer[:len(buffer)-hold])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the output held back as a possible secret prefix.
+func (w *RedactingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	_, err := w.writer.Write(w.pending)
+	w.pending = nil
+	return err
+}
+
+func (w *RedactingWriter) redact(buffer []byte) []byte {
+	for _, secret := range w.secrets {
+		buffer = bytes.ReplaceAll(buffer, secret, []byte(RedactedValue))
+	}
+	return buffer
+}
+
+// secretPrefixLength returns the length of the longest suffix of the buffer that is the start of a secret.
+func (w *RedactingWriter) secretPrefixLength(buffer []byte) int {
+	longest := 0
+	for _, secret := range w.secrets {
+		max := len(secret) - 1
+		if max > len(buffer) {
+			max = len(buffer)
+		}
+		for length := max; length > longest; length-- {
+			if bytes.HasPrefix(secret, buffer[len(buffer)-length:]) {
+				longest = length
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRedactingWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		writes  []string
+		want    string
+	}{
+		{
+			name:    "secret in a single write",
+			secrets: []string{"hetzner-token"},
+			writes:  []string{"invalid token hetzner-token\n"},
+			want:    "invalid token [REDACTED]\n",
+		},
+		{
+			name:    "secret split across two writes",
+			secrets: []string{"hetzner-token"},
+			writes:  []string{"token: hetzner-", "token\n"},
+			want:    "token: [REDACTED]\n",
+		},
+		{
+			name:    "secret split across many writes",
+			secrets: []string{"abcdef"},
+			writes:  []string{"a", "b", "c", "d", "e", "f", "\n"},
+			want:    "[REDACTED]\n",
+		},
+		{
+			name:    "secret split at the end of the output",
+			secrets: []string{"abcdef"},
+			writes:  []string{"key abc", "def"},
+			want:    "key [REDACTED]",
+		},
+		{
+			name:    "partial prefix that is not a secret",
+			secrets: []string{"abcdef"},
+			writes:  []string{"abc", "xyz\n"},
+			want:    "abcxyz\n",
+		},
+		{
+			name:    "multiple secrets",
+			secrets: []string{"first-secret", "second-secret"},
+			writes:  []string{"first-secret and second-", "secret\n"},
+			want:    "[REDACTED] and [REDACTED]\n",
+		},
+		{
+			name:    "secret containing another secret",
+			secrets: []string{"secret", "secret-token"},
+			writes:  []string{"secret-token\n"},
+			want:    "[REDACTED]\n",
+		},
+		{
+			name:    "short values are ignored",
+			secrets: []string{"", "yes"},
+			writes:  []string{"yes\n"},
+			want:    "yes\n",
+		},
+		{
+			name:    "repeated secret",
+			secrets: []string{"hetzner-token"},
+			writes:  []string{"hetzner-tokenhetzner-tok", "en"},
+			want:    "[REDACTED][REDACTED]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer := NewRedactingWriter(&output, tt.secrets...)
+
+			for _, write := range tt.writes {
+				n, err := writer.Write([]byte(write))
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if n != len(write) {
+					t.Errorf("expected %d bytes written, got %d", len(write), n)
+				}
+			}
+
+			err := writer.Flush()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, output.String())
+			}
+		})
+	}
+}
+
+func TestRedactingWriterNeverLeaksPrefix(t *testing.T) {
+	secret := "hcloud-api-token-value"
+	var output bytes.Buffer
+	writer := NewRedactingWriter(&output, secret)
+
+	// Write the secret one byte at a time and check that no intermediate output contains a part of it.
+	line := "Failed to create server: token " + secret + " is invalid\n"
+	for i := 0; i < len(line); i++ {
+		writer.Write([]byte{line[i]})
+		if strings.Contains(output.String(), secret[:minSecretLength]) {
+			t.Fatalf("output leaked a part of the secret: %q", output.String())
+		}
+	}
+	writer.Flush()
+
+	want := "Failed to create server: token [REDACTED] is invalid\n"
+	if output.String() != want {
+		t.Errorf("expected %q, got %q", want, output.String())
+	}
+}
+
+func TestIsSecretEnvVar(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"DAYTONA_SERVER_API_KEY", true},
+		{"HETZNER_API_TOKEN", true},
+		{"github_token", true},
+		{"DB_PASSWORD", true},
+		{"DAYTONA_WS_ID", false},
+		{"DAYTONA_SERVER_URL", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSecretEnvVar(tt.name); got != tt.want {
+			t.Errorf("IsSecretEnvVar(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,27 +1,49 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/daytonaio/daytona/pkg/provider"
 	"github.com/daytonaio/daytona/pkg/provider/manager"
-	"github.com/hashicorp/go-hclog"
 	hc_plugin "github.com/hashicorp/go-plugin"
 
+	logwriters "github.com/daytonaio/daytona-provider-hetzner/internal/log"
 	p "github.com/daytonaio/daytona-provider-hetzner/pkg/provider"
 )
 
 func main() {
-	logger := hclog.New(&hclog.LoggerOptions{
-		Level:      hclog.Trace,
-		Output:     os.Stderr,
-		JSONFormat: true,
-	})
+	if len(os.Args) > 1 {
+		var run func(args []string) error
+		switch os.Args[1] {
+		case "cost-report":
+			run = runCostReport
+		case "snapshot":
+			run = runSnapshot
+		}
+
+		if run != nil {
+			err := run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	logger := logwriters.NewLogger(os.Stderr)
+	hetznerProvider := &p.HetznerProvider{Logger: logger}
 	hc_plugin.Serve(&hc_plugin.ServeConfig{
 		HandshakeConfig: manager.ProviderHandshakeConfig,
 		Plugins: map[string]hc_plugin.Plugin{
-			"hetzner-provider": &provider.ProviderPlugin{Impl: &p.HetznerProvider{}},
+			"hetzner-provider": &provider.ProviderPlugin{Impl: hetznerProvider},
 		},
 		Logger: logger,
 	})
+
+	err := hetznerProvider.Shutdown()
+	if err != nil {
+		logger.Error("failed to shut down provider", "error", err)
+	}
 }
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	logwriters "github.com/daytonaio/daytona-provider-hetzner/internal/log"
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+)
+
+const (
+	agentLogPrefix            = "[agent] "
+	agentLogMaxLinesPerSecond = 20
+	agentLogRetryInterval     = 2 * time.Second
+	agentLogDialTimeout       = 10 * time.Second
+)
+
+// followAgentLog tails the Daytona agent log on the workspace server over the tailnet and writes its
+// lines to the log writer until the returned stop function is called. Connection failures are retried
+// since the agent only becomes reachable once it has joined the tailnet.
+func (h *HetznerProvider) followAgentLog(workspaceId string, logWriter io.Writer) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lineWriter := logwriters.NewRateLimitedLineWriter(logWriter, agentLogPrefix, agentLogMaxLinesPerSecond)
+
+	go func() {
+		defer close(done)
+		defer lineWriter.Flush()
+
+		linesRead := 0
+		for {
+			n, _ := h.tailAgentLog(ctx, workspaceId, linesRead, lineWriter)
+			linesRead += n
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(agentLogRetryInterval):
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// tailAgentLog streams the agent log starting after the given number of lines and returns the number
+// of lines read before the connection was closed.
+func (h *HetznerProvider) tailAgentLog(ctx context.Context, workspaceId string, skipLines int, lineWriter io.Writer) (int, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, agentLogDialTimeout)
+	sshClient, err := h.dialTailnetSsh(dialCtx, workspaceId)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	defer sshClient.Close()
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return 0, err
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+
+	err = session.Start(fmt.Sprintf("tail -n +%d -F %s 2>/dev/null", skipLines+1, hetznerutil.AgentLogFilePath))
+	if err != nil {
+		return 0, err
+	}
+
+	sessionDone := make(chan struct{})
+	defer close(sessionDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-sessionDone:
+		}
+	}()
+
+	linesRead := 0
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		linesRead++
+		lineWriter.Write(append(scanner.Bytes(), '\n'))
+	}
+
+	return linesRead, scanner.Err()
+}
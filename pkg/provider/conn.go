@@ -2,66 +2,367 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
 	"github.com/daytonaio/daytona/pkg/agent/ssh/config"
+	"github.com/daytonaio/daytona/pkg/common"
 	"github.com/daytonaio/daytona/pkg/docker"
-	"github.com/daytonaio/daytona/pkg/tailscale"
+	"github.com/daytonaio/daytona/pkg/workspace"
 	"github.com/docker/docker/client"
 	"github.com/google/uuid"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	cssh "golang.org/x/crypto/ssh"
+	"tailscale.com/ipn"
 	"tailscale.com/tsnet"
 )
 
+const (
+	tsnetUpTimeout          = 30 * time.Second
+	tsnetHealthCheckTimeout = 5 * time.Second
+	tsnetInstanceIdFile     = "instance-id"
+)
+
+// getTsnetConn returns a healthy tsnet connection, creating or recreating it when needed.
+// It is safe for concurrent use by the provider methods.
 func (h *HetznerProvider) getTsnetConn() (*tsnet.Server, error) {
+	h.tsnetMutex.Lock()
+	defer h.tsnetMutex.Unlock()
+
+	if h.tsnetConn != nil {
+		if isTsnetConnHealthy(h.tsnetConn) {
+			return h.tsnetConn, nil
+		}
+
+		h.tsnetConn.Close()
+		h.tsnetConn = nil
+	}
+
+	tsnetDir := filepath.Join(*h.BasePath, "tsnet")
+	instanceId, err := getTsnetInstanceId(tsnetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cleanupTsnetStateDirs(tsnetDir, instanceId)
+	if err != nil {
+		return nil, err
+	}
+
+	tsnetConn := &tsnet.Server{
+		AuthKey:    *h.NetworkKey,
+		ControlURL: *h.ServerUrl,
+		Dir:        filepath.Join(tsnetDir, instanceId),
+		Logf:       func(format string, args ...any) {},
+		UserLogf:   func(format string, args ...any) {},
+		Hostname:   fmt.Sprintf("hetzner-provider-%s", instanceId),
+		Ephemeral:  true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tsnetUpTimeout)
+	defer cancel()
+
+	_, err = tsnetConn.Up(ctx)
+	if err != nil {
+		tsnetConn.Close()
+		return nil, fmt.Errorf("%w. %w", err, common.ErrConnection)
+	}
+
+	h.tsnetConn = tsnetConn
+	return h.tsnetConn, nil
+}
+
+// closeTsnetConn shuts down the tsnet connection if one is open.
+func (h *HetznerProvider) closeTsnetConn() error {
+	h.tsnetMutex.Lock()
+	defer h.tsnetMutex.Unlock()
+
 	if h.tsnetConn == nil {
-		tsnetConn, err := tailscale.GetConnection(&tailscale.TsnetConnConfig{
-			AuthKey:    *h.NetworkKey,
-			ControlURL: *h.ServerUrl,
-			Dir:        filepath.Join(*h.BasePath, "tsnet", uuid.NewString()),
-			Logf:       func(format string, args ...any) {},
-			Hostname:   fmt.Sprintf("hetzner-provider-%s", uuid.NewString()),
-		})
+		return nil
+	}
+
+	err := h.tsnetConn.Close()
+	h.tsnetConn = nil
+	return err
+}
+
+// isTsnetConnHealthy checks that the local tailscale backend of the connection is still running.
+func isTsnetConnHealthy(tsnetConn *tsnet.Server) bool {
+	localClient, err := tsnetConn.LocalClient()
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tsnetHealthCheckTimeout)
+	defer cancel()
+
+	status, err := localClient.StatusWithoutPeers(ctx)
+	if err != nil {
+		return false
+	}
+
+	return status.BackendState == ipn.Running.String()
+}
+
+// getTsnetInstanceId returns the id of this provider instance, creating and persisting one on first use.
+// The id keeps the tsnet state directory and hostname stable across plugin restarts.
+func getTsnetInstanceId(tsnetDir string) (string, error) {
+	err := os.MkdirAll(tsnetDir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	idFile := filepath.Join(tsnetDir, tsnetInstanceIdFile)
+	id, err := os.ReadFile(idFile)
+	if err == nil && len(strings.TrimSpace(string(id))) > 0 {
+		return strings.TrimSpace(string(id)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	instanceId := uuid.NewString()
+	err = os.WriteFile(idFile, []byte(instanceId), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return instanceId, nil
+}
+
+// cleanupTsnetStateDirs removes state directories left behind by previous provider versions,
+// which created a new directory on every plugin start.
+func cleanupTsnetStateDirs(tsnetDir, instanceId string) error {
+	entries, err := os.ReadDir(tsnetDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == instanceId {
+			continue
+		}
+
+		err = os.RemoveAll(filepath.Join(tsnetDir, entry.Name()))
 		if err != nil {
-			return nil, err
+			return err
 		}
-		h.tsnetConn = tsnetConn
 	}
 
-	return h.tsnetConn, nil
+	return nil
 }
 
-func (h *HetznerProvider) waitForDial(workspaceId string, dialTimeout time.Duration) error {
+const (
+	defaultAgentReadyTimeout     = 10 * time.Minute
+	defaultAgentProbeMaxInterval = 15 * time.Second
+	agentProbeInitialInterval    = time.Second
+	agentProbeTimeout            = 10 * time.Second
+)
+
+// agentReadinessStage describes which part of the workspace startup is still pending.
+type agentReadinessStage string
+
+const (
+	stageServerBooting     agentReadinessStage = "server booting"
+	stageCloudInit         agentReadinessStage = "cloud-init running"
+	stageAgentNotOnTailnet agentReadinessStage = "agent not yet on tailnet"
+	stageSshHandshake      agentReadinessStage = "SSH server not yet accepting connections"
+	stageDockerApi         agentReadinessStage = "Docker API not yet answering"
+	stageReady             agentReadinessStage = "ready"
+)
+
+// waitForAgent probes the workspace with exponential backoff until the agent's SSH server completes a
+// handshake and the Docker API answers a ping. Progress is written to the log writer whenever the
+// pending stage changes.
+func (h *HetznerProvider) waitForAgent(ctx context.Context, ws *workspace.Workspace, targetOptions *types.TargetOptions, logWriter io.Writer) error {
 	tsnetConn, err := h.getTsnetConn()
 	if err != nil {
 		return err
 	}
 
-	dialStartTime := time.Now()
+	timeout := defaultAgentReadyTimeout
+	if targetOptions.AgentReadyTimeout > 0 {
+		timeout = time.Duration(targetOptions.AgentReadyTimeout) * time.Minute
+	}
+
+	maxInterval := defaultAgentProbeMaxInterval
+	if targetOptions.AgentProbeMaxInterval > 0 {
+		maxInterval = time.Duration(targetOptions.AgentProbeMaxInterval) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastStage agentReadinessStage
+	interval := agentProbeInitialInterval
 	for {
-		if time.Since(dialStartTime) > dialTimeout {
-			return fmt.Errorf("timeout: dialing timed out after %f minutes", dialTimeout.Minutes())
+		stage, err := h.probeAgent(ctx, tsnetConn, ws, targetOptions)
+		if err != nil {
+			return err
 		}
-
-		dialConn, err := tsnetConn.Dial(context.Background(), "tcp", fmt.Sprintf("%s:%d", workspaceId, config.SSH_PORT))
-		if err == nil {
-			dialConn.Close()
+		if stage == stageReady {
 			return nil
 		}
 
-		time.Sleep(time.Second)
+		if stage != lastStage {
+			logWriter.Write([]byte(fmt.Sprintf("Waiting for the workspace: %s\n", stage)))
+			lastStage = stage
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timeout: workspace not ready after %s, last pending stage: %s", timeout, stage)
+			}
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval = nextProbeInterval(interval, maxInterval)
 	}
 }
 
-func (h *HetznerProvider) getDockerClient(workspaceId string) (docker.IDockerClient, error) {
+// probeAgent returns the first stage of the workspace startup that is not complete yet. It returns an error
+// when the server cannot be looked up for a reason that retrying does not fix, e.g. a rejected token or a
+// deleted server.
+func (h *HetznerProvider) probeAgent(ctx context.Context, tsnetConn *tsnet.Server, ws *workspace.Workspace, targetOptions *types.TargetOptions) (agentReadinessStage, error) {
+	probeCtx, cancel := context.WithTimeout(ctx, agentProbeTimeout)
+	defer cancel()
+
+	server, err := hetznerutil.GetServer(ws, targetOptions)
+	if err != nil {
+		if !hetznerutil.IsRetryableError(err) {
+			return "", fmt.Errorf("failed to get the workspace server: %w", err)
+		}
+		return stageServerBooting, nil
+	}
+	if server == nil {
+		return "", fmt.Errorf("workspace server daytona-%s not found", ws.Id)
+	}
+	if server.Status != hcloud.ServerStatusRunning {
+		return stageServerBooting, nil
+	}
+
+	peerStage := getPeerStage(probeCtx, tsnetConn, ws.Id)
+	if peerStage != stageReady {
+		return peerStage, nil
+	}
+
+	if !h.isSshReady(probeCtx, ws.Id) {
+		return stageSshHandshake, nil
+	}
+
+	if !h.isDockerApiReady(probeCtx, ws.Id) {
+		return stageDockerApi, nil
+	}
+
+	return stageReady, nil
+}
+
+// getPeerStage checks whether the workspace agent has joined the tailnet. A peer that was never seen
+// means the agent is not installed yet, while a known offline peer is still connecting.
+func getPeerStage(ctx context.Context, tsnetConn *tsnet.Server, hostname string) agentReadinessStage {
+	localClient, err := tsnetConn.LocalClient()
+	if err != nil {
+		return stageAgentNotOnTailnet
+	}
+
+	status, err := localClient.Status(ctx)
+	if err != nil {
+		return stageAgentNotOnTailnet
+	}
+
+	for _, peer := range status.Peer {
+		if peer.HostName != hostname {
+			continue
+		}
+		if peer.Online {
+			return stageReady
+		}
+		return stageAgentNotOnTailnet
+	}
+
+	return stageCloudInit
+}
+
+// isSshReady checks that the agent's SSH server completes a handshake.
+func (h *HetznerProvider) isSshReady(ctx context.Context, workspaceId string) bool {
+	sshClient, err := h.dialTailnetSsh(ctx, workspaceId)
+	if err != nil {
+		return false
+	}
+
+	sshClient.Close()
+	return true
+}
+
+// dialTailnetSsh connects to the agent's SSH server over the tailnet. Unlike tailscale.NewSshClient,
+// the handshake is bound to the context deadline.
+func (h *HetznerProvider) dialTailnetSsh(ctx context.Context, workspaceId string) (*cssh.Client, error) {
 	tsnetConn, err := h.getTsnetConn()
 	if err != nil {
 		return nil, err
 	}
 
-	remoteHost := fmt.Sprintf("tcp://%s:2375", workspaceId)
-	cli, err := client.NewClientWithOpts(client.WithDialContext(tsnetConn.Dial), client.WithHost(remoteHost), client.WithAPIVersionNegotiation())
+	address := fmt.Sprintf("%s:%d", workspaceId, config.SSH_PORT)
+	conn, err := tsnetConn.Dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSshClient(ctx, conn, address, &cssh.ClientConfig{
+		HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+	})
+}
+
+// newSshClient performs the SSH handshake on the connection within the context deadline.
+func newSshClient(ctx context.Context, conn net.Conn, address string, sshConfig *cssh.ClientConfig) (*cssh.Client, error) {
+	deadline, ok := ctx.Deadline()
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := cssh.NewClientConn(conn, address, sshConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return cssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// isDockerApiReady checks that the Docker API on the workspace answers a ping.
+func (h *HetznerProvider) isDockerApiReady(ctx context.Context, workspaceId string) bool {
+	cli, err := h.getDockerApiClient(workspaceId)
+	if err != nil {
+		return false
+	}
+	defer cli.Close()
+
+	_, err = cli.Ping(ctx)
+	return err == nil
+}
+
+// nextProbeInterval doubles the probe interval up to the maximum.
+func nextProbeInterval(interval, maxInterval time.Duration) time.Duration {
+	interval *= 2
+	if interval > maxInterval {
+		return maxInterval
+	}
+	return interval
+}
+
+func (h *HetznerProvider) getDockerClient(workspaceId string) (docker.IDockerClient, error) {
+	cli, err := h.getDockerApiClient(workspaceId)
 	if err != nil {
 		return nil, err
 	}
@@ -70,3 +371,15 @@ func (h *HetznerProvider) getDockerClient(workspaceId string) (docker.IDockerCli
 		ApiClient: cli,
 	}), nil
 }
+
+func (h *HetznerProvider) getDockerApiClient(workspaceId string) (*client.Client, error) {
+	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return h.dialDockerSocket(ctx, workspaceId)
+	}
+
+	return client.NewClientWithOpts(
+		client.WithHost(fmt.Sprintf("unix://%s", dockerSocketPath)),
+		client.WithDialContext(dialContext),
+		client.WithAPIVersionNegotiation(),
+	)
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetTsnetInstanceId(t *testing.T) {
+	tsnetDir := filepath.Join(t.TempDir(), "tsnet")
+
+	instanceId, err := getTsnetInstanceId(tsnetDir)
+	if err != nil {
+		t.Fatalf("Error getting instance id: %s", err)
+	}
+	if instanceId == "" {
+		t.Fatalf("Expected instance id but got an empty string")
+	}
+
+	sameInstanceId, err := getTsnetInstanceId(tsnetDir)
+	if err != nil {
+		t.Fatalf("Error getting instance id: %s", err)
+	}
+	if sameInstanceId != instanceId {
+		t.Fatalf("Expected stable instance id %s, got %s", instanceId, sameInstanceId)
+	}
+}
+
+func TestCleanupTsnetStateDirs(t *testing.T) {
+	tsnetDir := t.TempDir()
+
+	for _, dir := range []string{"current", "old-1", "old-2"} {
+		err := os.MkdirAll(filepath.Join(tsnetDir, dir), 0700)
+		if err != nil {
+			t.Fatalf("Error creating state dir: %s", err)
+		}
+	}
+	err := os.WriteFile(filepath.Join(tsnetDir, tsnetInstanceIdFile), []byte("current"), 0600)
+	if err != nil {
+		t.Fatalf("Error writing instance id: %s", err)
+	}
+
+	err = cleanupTsnetStateDirs(tsnetDir, "current")
+	if err != nil {
+		t.Fatalf("Error cleaning up state dirs: %s", err)
+	}
+
+	entries, err := os.ReadDir(tsnetDir)
+	if err != nil {
+		t.Fatalf("Error reading tsnet dir: %s", err)
+	}
+
+	remaining := map[string]bool{}
+	for _, entry := range entries {
+		remaining[entry.Name()] = true
+	}
+	if len(remaining) != 2 || !remaining["current"] || !remaining[tsnetInstanceIdFile] {
+		t.Fatalf("Expected only the current state dir and instance id to remain, got %v", remaining)
+	}
+}
+
+func TestNextProbeInterval(t *testing.T) {
+	maxInterval := 15 * time.Second
+	intervals := []time.Duration{}
+
+	interval := agentProbeInitialInterval
+	for i := 0; i < 6; i++ {
+		interval = nextProbeInterval(interval, maxInterval)
+		intervals = append(intervals, interval)
+	}
+
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 15 * time.Second, 15 * time.Second, 15 * time.Second}
+	for i := range expected {
+		if intervals[i] != expected[i] {
+			t.Fatalf("Expected probe intervals %v, got %v", expected, intervals)
+		}
+	}
+}
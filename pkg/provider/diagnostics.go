@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+const (
+	diagnosticsSshTimeout = 15 * time.Second
+	diagnosticsLogLines   = 100
+)
+
+// collectDiagnostics writes the server state and, when the server is reachable over SSH, the tail of the
+// cloud-init and agent logs to the log writer. It is used when a workspace fails to become ready.
+func (h *HetznerProvider) collectDiagnostics(ws *workspace.Workspace, targetOptions *types.TargetOptions, logWriter io.Writer) {
+	logWriter.Write([]byte("Collecting diagnostics\n"))
+
+	server, err := hetznerutil.WriteServerDiagnostics(ws, targetOptions, logWriter)
+	if err != nil {
+		logWriter.Write([]byte("Failed to get server diagnostics: " + err.Error() + "\n"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsSshTimeout)
+	defer cancel()
+
+	sudo := "sudo "
+	sshClient, err := h.dialTailnetSsh(ctx, ws.Id)
+	if err != nil && server != nil && server.PublicNet.IPv4.IP != nil {
+		logWriter.Write([]byte("Workspace is not reachable over the tailnet, trying the diagnostics SSH key\n"))
+		sshClient, err = h.dialDiagnosticsSsh(ctx, ws.Id, server.PublicNet.IPv4.IP.String())
+		sudo = ""
+	}
+	if err != nil {
+		logWriter.Write([]byte("Workspace is not reachable over SSH, skipping log collection: " + err.Error() + "\n"))
+		return
+	}
+	defer sshClient.Close()
+
+	for _, logFile := range []string{"/var/log/cloud-init-output.log", hetznerutil.AgentLogFilePath} {
+		logWriter.Write([]byte(fmt.Sprintf("--- %s ---\n", logFile)))
+		err = runSshCommand(sshClient, fmt.Sprintf("%stail -n %d %s", sudo, diagnosticsLogLines, logFile), logWriter)
+		if err != nil {
+			logWriter.Write([]byte(fmt.Sprintf("Failed to read %s: %s\n", logFile, err)))
+		}
+	}
+}
+
+// dialDiagnosticsSsh connects to the public IP of the workspace server as root with the diagnostics SSH key.
+func (h *HetznerProvider) dialDiagnosticsSsh(ctx context.Context, workspaceId, ip string) (*cssh.Client, error) {
+	privateKey, err := os.ReadFile(h.getDiagnosticsKeyPath(workspaceId))
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := cssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{}
+	address := net.JoinHostPort(ip, "22")
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSshClient(ctx, conn, address, &cssh.ClientConfig{
+		User:            "root",
+		Auth:            []cssh.AuthMethod{cssh.PublicKeys(signer)},
+		HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+	})
+}
+
+// createDiagnosticsKey generates an SSH key pair for the workspace, stores the private key under
+// BasePath and returns the public key in authorized_keys format.
+func (h *HetznerProvider) createDiagnosticsKey(workspaceId string) (string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	sshPublicKey, err := cssh.NewPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	pemBlock, err := cssh.MarshalPrivateKey(privateKey, hetznerutil.DiagnosticsSshKeyName(workspaceId))
+	if err != nil {
+		return "", err
+	}
+
+	keyPath := h.getDiagnosticsKeyPath(workspaceId)
+	err = os.MkdirAll(filepath.Dir(keyPath), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(pemBlock), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return string(cssh.MarshalAuthorizedKey(sshPublicKey)), nil
+}
+
+func (h *HetznerProvider) removeDiagnosticsKey(workspaceId string) error {
+	return os.RemoveAll(filepath.Dir(h.getDiagnosticsKeyPath(workspaceId)))
+}
+
+func (h *HetznerProvider) getDiagnosticsKeyPath(workspaceId string) string {
+	return filepath.Join(*h.BasePath, "diagnostics", workspaceId, "id_ed25519")
+}
+
+func runSshCommand(sshClient *cssh.Client, command string, logWriter io.Writer) error {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdout = logWriter
+	session.Stderr = logWriter
+	return session.Run(command)
+}
@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestDiagnosticsKey(t *testing.T) {
+	basePath := t.TempDir()
+	h := &HetznerProvider{BasePath: &basePath}
+
+	authorizedKey, err := h.createDiagnosticsKey("123")
+	if err != nil {
+		t.Fatalf("Error creating diagnostics key: %s", err)
+	}
+
+	publicKey, _, _, _, err := cssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		t.Fatalf("Error parsing public key: %s", err)
+	}
+
+	privateKey, err := os.ReadFile(h.getDiagnosticsKeyPath("123"))
+	if err != nil {
+		t.Fatalf("Error reading private key: %s", err)
+	}
+
+	signer, err := cssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Error parsing private key: %s", err)
+	}
+
+	if !bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+		t.Fatalf("Expected private key to match the returned public key")
+	}
+
+	err = h.removeDiagnosticsKey("123")
+	if err != nil {
+		t.Fatalf("Error removing diagnostics key: %s", err)
+	}
+
+	_, err = os.Stat(h.getDiagnosticsKeyPath("123"))
+	if !os.IsNotExist(err) {
+		t.Fatalf("Expected diagnostics key to be removed")
+	}
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"net"
+
+	cssh "golang.org/x/crypto/ssh"
+)
+
+// dockerSocketPath is the Docker API socket on the workspace server. The Docker API is not exposed over
+// TCP; it is reached through a direct-streamlocal channel of the agent's SSH server on the tailnet.
+const dockerSocketPath = "/var/run/docker.sock"
+
+// dialDockerSocket opens a connection to the Docker socket of the workspace through its SSH tunnel.
+// A broken tunnel is replaced once before giving up.
+func (h *HetznerProvider) dialDockerSocket(ctx context.Context, workspaceId string) (net.Conn, error) {
+	sshClient, err := h.getDockerTunnel(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sshClient.Dial("unix", dockerSocketPath)
+	if err == nil {
+		return conn, nil
+	}
+
+	h.closeDockerTunnel(workspaceId, sshClient)
+
+	sshClient, err = h.getDockerTunnel(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return sshClient.Dial("unix", dockerSocketPath)
+}
+
+// getDockerTunnel returns the cached SSH connection of the workspace, creating it when needed.
+func (h *HetznerProvider) getDockerTunnel(ctx context.Context, workspaceId string) (*cssh.Client, error) {
+	h.dockerTunnelsMutex.Lock()
+	defer h.dockerTunnelsMutex.Unlock()
+
+	if sshClient, ok := h.dockerTunnels[workspaceId]; ok {
+		return sshClient, nil
+	}
+
+	sshClient, err := h.dialTailnetSsh(ctx, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.dockerTunnels == nil {
+		h.dockerTunnels = map[string]*cssh.Client{}
+	}
+	h.dockerTunnels[workspaceId] = sshClient
+
+	return sshClient, nil
+}
+
+// closeDockerTunnel closes the SSH connection of the workspace if it is still the cached one.
+func (h *HetznerProvider) closeDockerTunnel(workspaceId string, sshClient *cssh.Client) {
+	h.dockerTunnelsMutex.Lock()
+	defer h.dockerTunnelsMutex.Unlock()
+
+	if h.dockerTunnels[workspaceId] == sshClient {
+		delete(h.dockerTunnels, workspaceId)
+	}
+	sshClient.Close()
+}
+
+// closeWorkspaceDockerTunnel closes the SSH connection of the workspace, if there is one.
+func (h *HetznerProvider) closeWorkspaceDockerTunnel(workspaceId string) {
+	h.dockerTunnelsMutex.Lock()
+	defer h.dockerTunnelsMutex.Unlock()
+
+	if sshClient, ok := h.dockerTunnels[workspaceId]; ok {
+		sshClient.Close()
+		delete(h.dockerTunnels, workspaceId)
+	}
+}
+
+// closeDockerTunnels closes the SSH connections of all workspaces.
+func (h *HetznerProvider) closeDockerTunnels() {
+	h.dockerTunnelsMutex.Lock()
+	defer h.dockerTunnelsMutex.Unlock()
+
+	for workspaceId, sshClient := range h.dockerTunnels {
+		sshClient.Close()
+		delete(h.dockerTunnels, workspaceId)
+	}
+}
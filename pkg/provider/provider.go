@@ -1,11 +1,13 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/daytonaio/daytona-provider-hetzner/internal"
@@ -16,7 +18,9 @@ import (
 	"github.com/daytonaio/daytona/pkg/docker"
 	"github.com/daytonaio/daytona/pkg/ssh"
 	"github.com/daytonaio/daytona/pkg/tailscale"
+	"github.com/hashicorp/go-hclog"
 	"github.com/hetznercloud/hcloud-go/hcloud"
+	cssh "golang.org/x/crypto/ssh"
 	"tailscale.com/tsnet"
 
 	"github.com/daytonaio/daytona/pkg/logs"
@@ -36,7 +40,11 @@ type HetznerProvider struct {
 	ApiPort            *uint32
 	ServerPort         *uint32
 	LogsDir            *string
+	Logger             hclog.Logger
 	tsnetConn          *tsnet.Server
+	tsnetMutex         sync.Mutex
+	dockerTunnels      map[string]*cssh.Client
+	dockerTunnelsMutex sync.Mutex
 }
 
 func (h *HetznerProvider) Initialize(req provider.InitializeProviderRequest) (*util.Empty, error) {
@@ -50,6 +58,8 @@ func (h *HetznerProvider) Initialize(req provider.InitializeProviderRequest) (*u
 	h.ServerPort = &req.ServerPort
 	h.LogsDir = &req.LogsDir
 
+	hetznerutil.SetLogger(h.logger())
+
 	return new(util.Empty), nil
 }
 
@@ -66,36 +76,83 @@ func (h *HetznerProvider) GetTargetManifest() (*provider.ProviderTargetManifest,
 }
 
 func (h *HetznerProvider) GetPresetTargets() (*[]provider.ProviderTarget, error) {
-	return new([]provider.ProviderTarget), nil
+	info, err := h.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	presetsFilePath := ""
+	if h.BasePath != nil {
+		presetsFilePath = path.Join(*h.BasePath, types.PresetsFileName)
+	}
+
+	presets, err := types.GetPresets(presetsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	presetTargets := []provider.ProviderTarget{}
+	for _, preset := range hetznerutil.FilterPresets(presets) {
+		options, err := json.MarshalIndent(preset.Options, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+
+		presetTargets = append(presetTargets, provider.ProviderTarget{
+			Name:         preset.Name,
+			ProviderInfo: info,
+			Options:      string(options),
+		})
+	}
+
+	return &presetTargets, nil
 }
 
 func (h *HetznerProvider) CreateWorkspace(workspaceReq *provider.WorkspaceRequest) (*util.Empty, error) {
 	if h.DaytonaDownloadUrl == nil {
 		return nil, errors.New("DaytonaDownloadUrl not set. Did you forget to call Initialize")
 	}
-	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq.Workspace.Id)
+	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq)
 	defer cleanupFunc()
 
-	targetOptions, err := types.ParseTargetOptions(workspaceReq.TargetOptions)
+	targetOptions, err := parseTargetOptions(workspaceReq.TargetOptions, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to parse target options: " + err.Error() + "\n"))
 		return nil, err
 	}
 
+	diagnosticsPublicKey := ""
+	if targetOptions.DiagnosticsSshKey {
+		diagnosticsPublicKey, err = h.createDiagnosticsKey(workspaceReq.Workspace.Id)
+		if err != nil {
+			logWriter.Write([]byte("Failed to create diagnostics SSH key: " + err.Error() + "\n"))
+			return nil, err
+		}
+	}
+
 	initScript := fmt.Sprintf(`curl -sfL -H "Authorization: Bearer %s" %s | bash`, workspaceReq.Workspace.ApiKey, *h.DaytonaDownloadUrl)
-	err = hetznerutil.CreateWorkspace(workspaceReq.Workspace, targetOptions, initScript, logWriter)
+	err = hetznerutil.CreateWorkspace(workspaceReq.Workspace, targetOptions, initScript, diagnosticsPublicKey, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to create workspace: " + err.Error() + "\n"))
 		return nil, err
 	}
 
-	agentSpinner := logwriters.ShowSpinner(logWriter, "Waiting for the agent to start", "Agent started")
-	err = h.waitForDial(workspaceReq.Workspace.Id, 10*time.Minute)
-	close(agentSpinner)
+	// The agent log is only streamed until the workspace is ready.
+	stopAgentLog := func() {}
+	if targetOptions.StreamAgentLog {
+		stopAgentLog = h.followAgentLog(workspaceReq.Workspace.Id, logWriter)
+	}
+
+	agentStep := logwriters.NewProgress(logWriter, logwriters.DefaultProgressMode()).Start("Waiting for the agent to start")
+	defer agentStep.Stop()
+	err = h.waitForAgent(context.Background(), workspaceReq.Workspace, targetOptions, logWriter)
+	stopAgentLog()
 	if err != nil {
-		logWriter.Write([]byte("Failed to dial: " + err.Error() + "\n"))
+		agentStep.Fail(err)
+		h.collectDiagnostics(workspaceReq.Workspace, targetOptions, logWriter)
 		return nil, err
 	}
+	agentStep.Finish("Agent started")
 
 	client, err := h.getDockerClient(workspaceReq.Workspace.Id)
 	if err != nil {
@@ -104,7 +161,13 @@ func (h *HetznerProvider) CreateWorkspace(workspaceReq *provider.WorkspaceReques
 	}
 
 	workspaceDir := getWorkspaceDir(workspaceReq.Workspace.Id)
-	sshClient, err := tailscale.NewSshClient(h.tsnetConn, &ssh.SessionConfig{
+	tsnetConn, err := h.getTsnetConn()
+	if err != nil {
+		logWriter.Write([]byte("Failed to connect to the tailnet: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	sshClient, err := tailscale.NewSshClient(tsnetConn, &ssh.SessionConfig{
 		Hostname: workspaceReq.Workspace.Id,
 		Port:     config.SSH_PORT,
 	})
@@ -118,48 +181,72 @@ func (h *HetznerProvider) CreateWorkspace(workspaceReq *provider.WorkspaceReques
 }
 
 func (h *HetznerProvider) StartWorkspace(workspaceReq *provider.WorkspaceRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq.Workspace.Id)
+	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq)
 	defer cleanupFunc()
 
-	targetOptions, err := types.ParseTargetOptions(workspaceReq.TargetOptions)
+	targetOptions, err := parseTargetOptions(workspaceReq.TargetOptions, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to parse target options: " + err.Error() + "\n"))
 		return nil, err
 	}
 
-	err = h.waitForDial(workspaceReq.Workspace.Id, 10*time.Minute)
+	err = hetznerutil.StartWorkspace(workspaceReq.Workspace, targetOptions)
+	if err != nil {
+		logWriter.Write([]byte("Failed to start workspace: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	stopAgentLog := func() {}
+	if targetOptions.StreamAgentLog {
+		stopAgentLog = h.followAgentLog(workspaceReq.Workspace.Id, logWriter)
+	}
+
+	err = h.waitForAgent(context.Background(), workspaceReq.Workspace, targetOptions, logWriter)
+	stopAgentLog()
 	if err != nil {
 		logWriter.Write([]byte("Failed to dial: " + err.Error() + "\n"))
+		h.collectDiagnostics(workspaceReq.Workspace, targetOptions, logWriter)
 		return nil, err
 	}
 
-	return new(util.Empty), hetznerutil.StartWorkspace(workspaceReq.Workspace, targetOptions)
+	return new(util.Empty), nil
 }
 
 func (h *HetznerProvider) StopWorkspace(workspaceReq *provider.WorkspaceRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq.Workspace.Id)
+	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq)
 	defer cleanupFunc()
 
-	targetOptions, err := types.ParseTargetOptions(workspaceReq.TargetOptions)
+	targetOptions, err := parseTargetOptions(workspaceReq.TargetOptions, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to parse target options: " + err.Error() + "\n"))
 		return nil, err
 	}
 
+	h.closeWorkspaceDockerTunnel(workspaceReq.Workspace.Id)
+
 	return new(util.Empty), hetznerutil.StopWorkspace(workspaceReq.Workspace, targetOptions)
 }
 
 func (h *HetznerProvider) DestroyWorkspace(workspaceReq *provider.WorkspaceRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq.Workspace.Id)
+	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq)
 	defer cleanupFunc()
 
-	targetOptions, err := types.ParseTargetOptions(workspaceReq.TargetOptions)
+	targetOptions, err := parseTargetOptions(workspaceReq.TargetOptions, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to parse target options: " + err.Error() + "\n"))
 		return nil, err
 	}
 
-	return new(util.Empty), hetznerutil.DeleteWorkspace(workspaceReq.Workspace, targetOptions)
+	h.closeWorkspaceDockerTunnel(workspaceReq.Workspace.Id)
+
+	force := targetOptions.ForceDeletes(workspaceReq.Workspace.Id)
+	err = hetznerutil.DeleteWorkspace(workspaceReq.Workspace, targetOptions, force, logWriter)
+	if err != nil {
+		logWriter.Write([]byte("Failed to delete workspace: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	return new(util.Empty), h.removeDiagnosticsKey(workspaceReq.Workspace.Id)
 }
 
 func (h *HetznerProvider) GetWorkspaceInfo(workspaceReq *provider.WorkspaceRequest) (*workspace.WorkspaceInfo, error) {
@@ -185,7 +272,7 @@ func (h *HetznerProvider) GetWorkspaceInfo(workspaceReq *provider.WorkspaceReque
 }
 
 func (h *HetznerProvider) CreateProject(projectReq *provider.ProjectRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq.Project.WorkspaceId, projectReq.Project.Name)
+	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq)
 	defer cleanupFunc()
 	logWriter.Write([]byte("\033[?25h\n"))
 
@@ -195,7 +282,13 @@ func (h *HetznerProvider) CreateProject(projectReq *provider.ProjectRequest) (*u
 		return nil, err
 	}
 
-	sshClient, err := tailscale.NewSshClient(h.tsnetConn, &ssh.SessionConfig{
+	tsnetConn, err := h.getTsnetConn()
+	if err != nil {
+		logWriter.Write([]byte("Failed to connect to the tailnet: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	sshClient, err := tailscale.NewSshClient(tsnetConn, &ssh.SessionConfig{
 		Hostname: projectReq.Project.WorkspaceId,
 		Port:     config.SSH_PORT,
 	})
@@ -221,7 +314,7 @@ func (h *HetznerProvider) StartProject(projectReq *provider.ProjectRequest) (*ut
 	if h.DaytonaDownloadUrl == nil {
 		return nil, errors.New("DaytonaDownloadUrl not set. Did you forget to call Initialize")
 	}
-	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq.Project.WorkspaceId, projectReq.Project.Name)
+	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq)
 	defer cleanupFunc()
 
 	dockerClient, err := h.getDockerClient(projectReq.Project.WorkspaceId)
@@ -230,7 +323,13 @@ func (h *HetznerProvider) StartProject(projectReq *provider.ProjectRequest) (*ut
 		return nil, err
 	}
 
-	sshClient, err := tailscale.NewSshClient(h.tsnetConn, &ssh.SessionConfig{
+	tsnetConn, err := h.getTsnetConn()
+	if err != nil {
+		logWriter.Write([]byte("Failed to connect to the tailnet: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	sshClient, err := tailscale.NewSshClient(tsnetConn, &ssh.SessionConfig{
 		Hostname: projectReq.Project.WorkspaceId,
 		Port:     config.SSH_PORT,
 	})
@@ -253,7 +352,7 @@ func (h *HetznerProvider) StartProject(projectReq *provider.ProjectRequest) (*ut
 }
 
 func (h *HetznerProvider) StopProject(projectReq *provider.ProjectRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq.Project.WorkspaceId, projectReq.Project.Name)
+	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq)
 	defer cleanupFunc()
 
 	dockerClient, err := h.getDockerClient(projectReq.Project.WorkspaceId)
@@ -266,7 +365,7 @@ func (h *HetznerProvider) StopProject(projectReq *provider.ProjectRequest) (*uti
 }
 
 func (h *HetznerProvider) DestroyProject(projectReq *provider.ProjectRequest) (*util.Empty, error) {
-	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq.Project.WorkspaceId, projectReq.Project.Name)
+	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq)
 	defer cleanupFunc()
 
 	dockerClient, err := h.getDockerClient(projectReq.Project.WorkspaceId)
@@ -275,7 +374,13 @@ func (h *HetznerProvider) DestroyProject(projectReq *provider.ProjectRequest) (*
 		return nil, err
 	}
 
-	sshClient, err := tailscale.NewSshClient(h.tsnetConn, &ssh.SessionConfig{
+	tsnetConn, err := h.getTsnetConn()
+	if err != nil {
+		logWriter.Write([]byte("Failed to connect to the tailnet: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	sshClient, err := tailscale.NewSshClient(tsnetConn, &ssh.SessionConfig{
 		Hostname: projectReq.Project.WorkspaceId,
 		Port:     config.SSH_PORT,
 	})
@@ -289,7 +394,7 @@ func (h *HetznerProvider) DestroyProject(projectReq *provider.ProjectRequest) (*
 }
 
 func (h *HetznerProvider) GetProjectInfo(projectReq *provider.ProjectRequest) (*project.ProjectInfo, error) {
-	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq.Project.WorkspaceId, projectReq.Project.Name)
+	logWriter, cleanupFunc := h.getProjectLogWriter(projectReq)
 	defer cleanupFunc()
 
 	dockerClient, err := h.getDockerClient(projectReq.Project.WorkspaceId)
@@ -302,10 +407,10 @@ func (h *HetznerProvider) GetProjectInfo(projectReq *provider.ProjectRequest) (*
 }
 
 func (h *HetznerProvider) getWorkspaceInfo(workspaceReq *provider.WorkspaceRequest) (*workspace.WorkspaceInfo, error) {
-	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq.Workspace.Id)
+	logWriter, cleanupFunc := h.getWorkspaceLogWriter(workspaceReq)
 	defer cleanupFunc()
 
-	targetOptions, err := types.ParseTargetOptions(workspaceReq.TargetOptions)
+	targetOptions, err := parseTargetOptions(workspaceReq.TargetOptions, logWriter)
 	if err != nil {
 		logWriter.Write([]byte("Failed to parse target options: " + err.Error() + "\n"))
 		return nil, err
@@ -316,8 +421,35 @@ func (h *HetznerProvider) getWorkspaceInfo(workspaceReq *provider.WorkspaceReque
 		logWriter.Write([]byte("Failed to get server: " + err.Error() + "\n"))
 		return nil, err
 	}
+	if server == nil {
+		err = fmt.Errorf("server daytona-%s not found", workspaceReq.Workspace.Id)
+		logWriter.Write([]byte("Failed to get server: " + err.Error() + "\n"))
+		return nil, err
+	}
+
+	// Volumes, pricing and snapshots only add details to the metadata, so the server info is returned without them
+	// when they cannot be fetched.
+	volumes, err := hetznerutil.GetServerVolumes(server, targetOptions)
+	if err != nil {
+		logWriter.Write([]byte("Failed to get server volumes: " + err.Error() + "\n"))
+		volumes = nil
+	}
+
+	pricing, err := hetznerutil.GetPricing(targetOptions)
+	if err != nil {
+		logWriter.Write([]byte("Failed to get pricing: " + err.Error() + "\n"))
+		pricing = nil
+	}
+
+	metadata := types.ToWorkspaceMetadata(server, volumes, pricing)
+
+	snapshots, err := hetznerutil.GetWorkspaceSnapshots(workspaceReq.Workspace.Id, targetOptions)
+	if err != nil {
+		logWriter.Write([]byte("Failed to get snapshots: " + err.Error() + "\n"))
+	} else {
+		metadata.Snapshots = types.ToSnapshotMetadata(snapshots, time.Now())
+	}
 
-	metadata := types.ToWorkspaceMetadata(server)
 	jsonMetadata, err := json.Marshal(metadata)
 	if err != nil {
 		return nil, err
@@ -329,37 +461,82 @@ func (h *HetznerProvider) getWorkspaceInfo(workspaceReq *provider.WorkspaceReque
 	}, nil
 }
 
-func (h *HetznerProvider) getWorkspaceLogWriter(workspaceId string) (io.Writer, func()) {
-	logWriter := io.MultiWriter(&logwriters.InfoLogWriter{})
-	cleanupFunc := func() {}
+func (h *HetznerProvider) getWorkspaceLogWriter(workspaceReq *provider.WorkspaceRequest) (*logwriters.RedactingWriter, func()) {
+	workspaceId := workspaceReq.Workspace.Id
+	loggerWriter := logwriters.NewLogWriter(h.logger().With("workspace_id", workspaceId), hclog.Info)
+	logWriter := io.Writer(loggerWriter)
+	closeFunc := func() {}
 
 	if h.LogsDir != nil {
 		loggerFactory := logs.NewLoggerFactory(h.LogsDir, nil)
 		wsLogWriter := loggerFactory.CreateWorkspaceLogger(workspaceId, logs.LogSourceProvider)
-		logWriter = io.MultiWriter(&logwriters.InfoLogWriter{}, wsLogWriter)
-		cleanupFunc = func() { wsLogWriter.Close() }
+		logWriter = io.MultiWriter(loggerWriter, wsLogWriter)
+		closeFunc = func() { wsLogWriter.Close() }
 	}
 
-	return logWriter, cleanupFunc
+	redactingWriter := logwriters.NewRedactingWriter(logWriter, workspaceSecrets(workspaceReq)...)
+	cleanupFunc := func() {
+		redactingWriter.Flush()
+		loggerWriter.Flush()
+		closeFunc()
+	}
+
+	return redactingWriter, cleanupFunc
 }
 
-func (h *HetznerProvider) getProjectLogWriter(workspaceId string, projectName string) (io.Writer, func()) {
-	logWriter := io.MultiWriter(&logwriters.InfoLogWriter{})
-	cleanupFunc := func() {}
+func (h *HetznerProvider) getProjectLogWriter(projectReq *provider.ProjectRequest) (io.Writer, func()) {
+	workspaceId := projectReq.Project.WorkspaceId
+	projectName := projectReq.Project.Name
+	loggerWriter := logwriters.NewLogWriter(h.logger().With("workspace_id", workspaceId, "project", projectName), hclog.Info)
+	logWriter := io.Writer(loggerWriter)
+	closeFunc := func() {}
 
 	if h.LogsDir != nil {
 		loggerFactory := logs.NewLoggerFactory(h.LogsDir, nil)
 		projectLogWriter := loggerFactory.CreateProjectLogger(workspaceId, projectName, logs.LogSourceProvider)
-		logWriter = io.MultiWriter(&logwriters.InfoLogWriter{}, projectLogWriter)
-		cleanupFunc = func() { projectLogWriter.Close() }
+		logWriter = io.MultiWriter(loggerWriter, projectLogWriter)
+		closeFunc = func() { projectLogWriter.Close() }
+	}
+
+	redactingWriter := logwriters.NewRedactingWriter(logWriter, projectSecrets(projectReq)...)
+	cleanupFunc := func() {
+		redactingWriter.Flush()
+		loggerWriter.Flush()
+		closeFunc()
 	}
 
-	return logWriter, cleanupFunc
+	return redactingWriter, cleanupFunc
 }
 
-func (h *HetznerProvider) CheckRequirements() (*[]provider.RequirementStatus, error) {
-	results := []provider.RequirementStatus{}
-	return &results, nil
+// logger returns the logger passed from main, falling back to the default hclog logger.
+func (h *HetznerProvider) logger() hclog.Logger {
+	if h.Logger == nil {
+		return hclog.Default()
+	}
+	return h.Logger
+}
+
+// Shutdown releases the resources held by the provider. It is called once the plugin stops serving.
+func (h *HetznerProvider) Shutdown() error {
+	h.closeDockerTunnels()
+	return h.closeTsnetConn()
+}
+
+// parseTargetOptions parses the target options and writes the parse warnings, such as unknown options, to the log.
+func parseTargetOptions(optionsJson string, logWriter *logwriters.RedactingWriter) (*types.TargetOptions, error) {
+	targetOptions, err := types.ParseTargetOptions(optionsJson)
+	if err != nil {
+		return nil, err
+	}
+
+	// A token from a file, a command or the environment is only known once it is resolved.
+	logWriter.AddSecret(targetOptions.APIToken)
+
+	for _, warning := range targetOptions.Warnings {
+		logWriter.Write([]byte("Warning: " + warning + "\n"))
+	}
+
+	return targetOptions, nil
 }
 
 func getWorkspaceDir(workspaceId string) string {
@@ -56,7 +56,12 @@ func TestWorkspaceInfo(t *testing.T) {
 		t.Fatalf("Error getting server: %s", err)
 	}
 
-	expectedMetadata := types.ToWorkspaceMetadata(server)
+	volumes, err := hetznerutil.GetServerVolumes(server, targetOptions)
+	if err != nil {
+		t.Fatalf("Error getting server volumes: %s", err)
+	}
+
+	expectedMetadata := types.ToWorkspaceMetadata(server, volumes, nil)
 
 	if expectedMetadata.ServerID != workspaceMetadata.ServerID {
 		t.Fatalf("Expected server id %d, got %d",
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/provider"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+const controlUrlTimeout = 10 * time.Second
+
+func (h *HetznerProvider) CheckRequirements() (*[]provider.RequirementStatus, error) {
+	results := checkApiToken()
+	results = append(results, checkControlUrl(h.ServerUrl), checkBasePath(h.BasePath))
+	return &results, nil
+}
+
+// checkApiToken checks the token available to the provider without target options, e.g. HETZNER_API_TOKEN,
+// and whether it can create resources.
+func checkApiToken() []provider.RequirementStatus {
+	tokenStatus := provider.RequirementStatus{Name: "Hetzner API token"}
+	writeStatus := provider.RequirementStatus{Name: "Hetzner API token write permission"}
+
+	opts, err := types.ParseTargetOptions("{}")
+	if errors.Is(err, types.ErrTokenNotSet) {
+		// Targets can carry their own token, so a missing default token is not an error.
+		tokenStatus.Met = true
+		tokenStatus.Reason = "No default Hetzner API token found, every target has to set API Token, API Token File, API Token Command or Context"
+		return []provider.RequirementStatus{tokenStatus}
+	}
+	if err != nil {
+		tokenStatus.Reason = "Failed to resolve the default Hetzner API token: " + err.Error()
+		return []provider.RequirementStatus{tokenStatus}
+	}
+
+	err = hetznerutil.CheckToken(opts)
+	if err != nil {
+		tokenStatus.Reason = "The Hetzner API token was rejected: " + err.Error()
+		if hcloud.IsError(err, hcloud.ErrorCodeUnauthorized) {
+			tokenStatus.Reason = "The Hetzner API token is invalid or was revoked. Generate a new token in the Hetzner Cloud Console under Security > API Tokens"
+		}
+		return []provider.RequirementStatus{tokenStatus}
+	}
+	tokenStatus.Met = true
+	tokenStatus.Reason = "Hetzner API token is valid"
+
+	err = hetznerutil.CheckWritePermission(opts)
+	switch {
+	case errors.Is(err, hetznerutil.ErrReadOnlyToken):
+		writeStatus.Reason = "The Hetzner API token is read-only. Generate a token with Read & Write permission"
+	case err != nil:
+		writeStatus.Reason = err.Error()
+	default:
+		writeStatus.Met = true
+		writeStatus.Reason = "Hetzner API token has write permission"
+	}
+
+	return []provider.RequirementStatus{tokenStatus, writeStatus}
+}
+
+// checkControlUrl checks that the tailnet control server of Daytona can be reached.
+func checkControlUrl(serverUrl *string) provider.RequirementStatus {
+	status := provider.RequirementStatus{Name: "Tailnet control server"}
+	if serverUrl == nil || *serverUrl == "" {
+		status.Reason = "The Daytona server URL is not set. Did you forget to call Initialize"
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), controlUrlTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *serverUrl, nil)
+	if err != nil {
+		status.Reason = fmt.Sprintf("Invalid tailnet control URL %s: %s", *serverUrl, err)
+		return status
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		status.Reason = fmt.Sprintf("The tailnet control URL %s is not reachable: %s. Check that the Daytona server URL is reachable from this machine", *serverUrl, err)
+		return status
+	}
+	resp.Body.Close()
+
+	status.Met = true
+	status.Reason = fmt.Sprintf("Tailnet control URL %s is reachable", *serverUrl)
+	return status
+}
+
+// checkBasePath checks that the provider can store its tailnet state and diagnostics keys.
+func checkBasePath(basePath *string) provider.RequirementStatus {
+	status := provider.RequirementStatus{Name: "Base path"}
+	if basePath == nil || *basePath == "" {
+		status.Reason = "The provider base path is not set. Did you forget to call Initialize"
+		return status
+	}
+
+	err := os.MkdirAll(*basePath, 0755)
+	if err != nil {
+		status.Reason = fmt.Sprintf("Failed to create the base path %s: %s. Check the permissions of its parent directory", *basePath, err)
+		return status
+	}
+
+	file, err := os.CreateTemp(*basePath, ".write-check-*")
+	if err != nil {
+		status.Reason = fmt.Sprintf("The base path %s is not writable: %s. Check its owner and permissions", *basePath, err)
+		return status
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	status.Met = true
+	status.Reason = fmt.Sprintf("Base path %s is writable", *basePath)
+	return status
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckControlUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	reachable := server.URL
+	if status := checkControlUrl(&reachable); !status.Met {
+		t.Errorf("expected %s to be reachable: %s", reachable, status.Reason)
+	}
+
+	server.Close()
+	if status := checkControlUrl(&reachable); status.Met || status.Reason == "" {
+		t.Errorf("expected %s to be unreachable with a reason, got %+v", reachable, status)
+	}
+
+	if status := checkControlUrl(nil); status.Met {
+		t.Errorf("expected an unset control URL to fail")
+	}
+}
+
+func TestCheckBasePath(t *testing.T) {
+	basePath := filepath.Join(t.TempDir(), "providers", "hetzner")
+	if status := checkBasePath(&basePath); !status.Met {
+		t.Errorf("expected %s to be writable: %s", basePath, status.Reason)
+	}
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		t.Fatalf("failed to read base path: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the write check to clean up, found %d entries", len(entries))
+	}
+
+	if os.Getuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	readOnly := t.TempDir()
+	err = os.Chmod(readOnly, 0555)
+	if err != nil {
+		t.Fatalf("failed to change permissions: %s", err)
+	}
+	if status := checkBasePath(&readOnly); status.Met || status.Reason == "" {
+		t.Errorf("expected %s not to be writable, got %+v", readOnly, status)
+	}
+}
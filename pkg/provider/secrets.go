@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"encoding/json"
+
+	logwriters "github.com/daytonaio/daytona-provider-hetzner/internal/log"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/containerregistry"
+	"github.com/daytonaio/daytona/pkg/provider"
+)
+
+// workspaceSecrets returns the values that must be redacted from the logs of a workspace. The resolved API token
+// is added by parseTargetOptions, since it is only known once the target options are parsed.
+func workspaceSecrets(workspaceReq *provider.WorkspaceRequest) []string {
+	secrets := targetOptionsSecrets(workspaceReq.TargetOptions)
+	if workspaceReq.Workspace == nil {
+		return secrets
+	}
+
+	secrets = append(secrets, workspaceReq.Workspace.ApiKey)
+	return append(secrets, envVarSecrets(workspaceReq.Workspace.EnvVars)...)
+}
+
+// projectSecrets returns the values that must be redacted from the logs of a project.
+func projectSecrets(projectReq *provider.ProjectRequest) []string {
+	secrets := targetOptionsSecrets(projectReq.TargetOptions)
+
+	if projectReq.Project != nil {
+		secrets = append(secrets, projectReq.Project.ApiKey)
+		secrets = append(secrets, envVarSecrets(projectReq.Project.EnvVars)...)
+	}
+	if projectReq.GitProviderConfig != nil {
+		secrets = append(secrets, projectReq.GitProviderConfig.Token)
+	}
+	for _, registry := range []*containerregistry.ContainerRegistry{projectReq.ContainerRegistry, projectReq.BuilderContainerRegistry} {
+		if registry != nil {
+			secrets = append(secrets, registry.Password)
+		}
+	}
+
+	return secrets
+}
+
+// targetOptionsSecrets returns the API Token option. The options are only decoded, not parsed, so that building a
+// log writer never runs the API Token Command or reads the hcloud CLI config.
+func targetOptionsSecrets(targetOptionsJson string) []string {
+	var targetOptions types.TargetOptions
+	err := json.Unmarshal([]byte(targetOptionsJson), &targetOptions)
+	if err != nil {
+		return nil
+	}
+	return []string{targetOptions.APIToken}
+}
+
+func envVarSecrets(envVars map[string]string) []string {
+	var secrets []string
+	for name, value := range envVars {
+		if logwriters.IsSecretEnvVar(name) {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logwriters "github.com/daytonaio/daytona-provider-hetzner/internal/log"
+	"github.com/daytonaio/daytona/pkg/containerregistry"
+	"github.com/daytonaio/daytona/pkg/gitprovider"
+	"github.com/daytonaio/daytona/pkg/provider"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/daytonaio/daytona/pkg/workspace/project"
+)
+
+func TestWorkspaceSecrets(t *testing.T) {
+	workspaceReq := &provider.WorkspaceRequest{
+		TargetOptions: `{"API Token": "hetzner-token"}`,
+		Workspace: &workspace.Workspace{
+			Id:     "ws1",
+			ApiKey: "workspace-api-key",
+			EnvVars: map[string]string{
+				"DAYTONA_SERVER_API_KEY": "server-api-key",
+				"DAYTONA_SERVER_URL":     "https://daytona.example.com",
+			},
+		},
+	}
+
+	var output bytes.Buffer
+	writer := logwriters.NewRedactingWriter(&output, workspaceSecrets(workspaceReq)...)
+	writer.Write([]byte("token hetzner-token, key workspace-api-key, server-api-key, url https://daytona.example.com\n"))
+	writer.Flush()
+
+	want := "token [REDACTED], key [REDACTED], [REDACTED], url https://daytona.example.com\n"
+	if output.String() != want {
+		t.Errorf("expected %q, got %q", want, output.String())
+	}
+}
+
+func TestProjectSecrets(t *testing.T) {
+	projectReq := &provider.ProjectRequest{
+		TargetOptions: `{"API Token": "hetzner-token"}`,
+		Project: &project.Project{
+			Name:        "project1",
+			WorkspaceId: "ws1",
+			ApiKey:      "project-api-key",
+			EnvVars:     map[string]string{"NPM_TOKEN": "npm-token"},
+		},
+		GitProviderConfig: &gitprovider.GitProviderConfig{Token: "git-token"},
+		ContainerRegistry: &containerregistry.ContainerRegistry{Password: "registry-password"},
+	}
+
+	secrets := strings.Join(projectSecrets(projectReq), ",")
+	for _, secret := range []string{"hetzner-token", "project-api-key", "npm-token", "git-token", "registry-password"} {
+		if !strings.Contains(secrets, secret) {
+			t.Errorf("expected %q to be a secret, got %q", secret, secrets)
+		}
+	}
+}
+
+func TestTargetOptionsSecretsDoesNotResolveToken(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "resolved")
+	secrets := targetOptionsSecrets(`{"API Token": "hetzner-token", "API Token Command": "touch ` + marker + `"}`)
+
+	if len(secrets) != 1 || secrets[0] != "hetzner-token" {
+		t.Errorf("expected the API Token option as the only secret, got %v", secrets)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("expected the API Token Command not to run")
+	}
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// checkProjectLimits checks that the Hetzner project has room for one more server and the given number of new
+// volumes.
+func checkProjectLimits(client *hcloud.Client, serverType *hcloud.ServerType, newVolumes int, opts *types.TargetOptions) error {
+	if opts.ServerLimit <= 0 && opts.CoreLimit <= 0 && opts.VolumeLimit <= 0 {
+		return nil
+	}
+
+	servers, err := client.Server.All(context.Background())
+	if err != nil {
+		return err
+	}
+
+	volumes, err := client.Volume.All(context.Background())
+	if err != nil {
+		return err
+	}
+
+	usage := types.ProjectUsage{
+		Servers: len(servers),
+		Volumes: len(volumes),
+	}
+	for _, server := range servers {
+		if server.ServerType != nil {
+			usage.Cores += server.ServerType.Cores
+		}
+	}
+
+	return types.CheckProjectLimits(usage, serverType.Cores, newVolumes, opts)
+}
+
+// selectLocation returns the first candidate location in which the server type is available.
+func selectLocation(client *hcloud.Client, serverType *hcloud.ServerType, opts *types.TargetOptions, logWriter io.Writer) (*hcloud.Location, error) {
+	datacenters, err := client.Datacenter.All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, locationName := range opts.CandidateLocations() {
+		location, _, err := client.Location.GetByName(context.Background(), locationName)
+		if err != nil {
+			return nil, err
+		}
+		if location == nil {
+			logWriter.Write([]byte(fmt.Sprintf("Location %s does not exist, skipping\n", locationName)))
+			continue
+		}
+
+		if isServerTypeAvailable(datacenters, serverType, location) {
+			if locationName != opts.Location {
+				logWriter.Write([]byte(fmt.Sprintf("Server type %s is unavailable in %s, using %s instead\n", serverType.Name, opts.Location, locationName)))
+			}
+			return location, nil
+		}
+	}
+
+	return nil, fmt.Errorf("server type %s is not available in any of the locations %v", serverType.Name, opts.CandidateLocations())
+}
+
+// isServerTypeAvailable checks whether any datacenter in the location can currently create the server type.
+func isServerTypeAvailable(datacenters []*hcloud.Datacenter, serverType *hcloud.ServerType, location *hcloud.Location) bool {
+	for _, datacenter := range datacenters {
+		if datacenter.Location == nil || datacenter.Location.Name != location.Name {
+			continue
+		}
+		for _, available := range datacenter.ServerTypes.Available {
+			if available.ID == serverType.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wrapLimitError names the exceeded project limit, or the missing write permission, when Hetzner rejects the
+// creation of a resource.
+func wrapLimitError(resource string, err error) error {
+	switch {
+	case hcloud.IsError(err, hcloud.ErrorCodeResourceLimitExceeded):
+		return fmt.Errorf("project %s limit exceeded: %w", resource, err)
+	case hcloud.IsError(err, hcloud.ErrorCodeForbidden):
+		return fmt.Errorf("failed to create %s: %w: %w", resource, ErrReadOnlyToken, err)
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// correlationIdHeader holds the id Hetzner assigns to every API request. It is needed for support requests.
+const correlationIdHeader = "X-Correlation-Id"
+
+var logger = hclog.NewNullLogger()
+
+// apiEndpoint is the Hetzner API that clients talk to. Tests point it to a local server.
+var apiEndpoint = hcloud.Endpoint
+
+// SetLogger sets the logger used for Hetzner API requests and actions.
+func SetLogger(l hclog.Logger) {
+	logger = l
+}
+
+// newClient returns a Hetzner client that logs every API request at debug level and resolves the API token
+// again when the API rejects it.
+func newClient(opts *types.TargetOptions) *hcloud.Client {
+	return hcloud.NewClient(
+		hcloud.WithEndpoint(apiEndpoint),
+		hcloud.WithToken(opts.APIToken),
+		hcloud.WithHTTPClient(&http.Client{
+			Transport: &credentialsTransport{
+				opts: opts,
+				next: &loggingTransport{next: http.DefaultTransport},
+			},
+		}),
+	)
+}
+
+// credentialsTransport retries a request once with a freshly resolved token when the API returns 401, e.g.
+// after the token was rotated in the password manager that the API Token Command reads from. The token is
+// kept in the credential resolver of the options, whose lock is shared by all clients of the same options.
+type credentialsTransport struct {
+	opts *types.TargetOptions
+	next http.RoundTripper
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	credentials := t.opts.Credentials()
+
+	rejected := t.token(credentials)
+	resp, err := t.next.RoundTrip(t.withToken(req, rejected))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err := credentials.Refresh()
+	if err != nil {
+		logger.Warn("failed to resolve the API token again", "error", err)
+		return resp, nil
+	}
+	if token == "" || token == rejected {
+		return resp, nil
+	}
+
+	logger.Info("retrying hetzner api request with a newly resolved token", "method", req.Method, "path", req.URL.Path)
+
+	retry := t.withToken(req, token)
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+	}
+
+	resp.Body.Close()
+	return t.next.RoundTrip(retry)
+}
+
+// token returns the current token of the resolver, or the token the options were parsed with if it cannot be
+// resolved.
+func (t *credentialsTransport) token(credentials *types.CredentialResolver) string {
+	token, err := credentials.Token()
+	if err != nil || token == "" {
+		return t.opts.APIToken
+	}
+	return token
+}
+
+func (t *credentialsTransport) withToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		logger.Debug("hetzner api request failed", "method", req.Method, "path", req.URL.Path, "error", err)
+		return nil, err
+	}
+
+	logger.Debug("hetzner api request",
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", resp.StatusCode,
+		"request_id", resp.Header.Get(correlationIdHeader),
+		"duration", time.Since(start),
+	)
+	return resp, nil
+}
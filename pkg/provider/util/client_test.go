@@ -0,0 +1,91 @@
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+)
+
+func TestCredentialsTransportRefreshesRejectedToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("old-token"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token file: %s", err)
+	}
+	defer types.FileCredentialSource{Path: tokenFile}.Invalidate()
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	opts, err := types.ParseTargetOptions(`{"API Token File": "` + tokenFile + `"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The token is rotated after it was resolved.
+	err = os.WriteFile(tokenFile, []byte("new-token"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token file: %s", err)
+	}
+
+	client := &http.Client{Transport: &credentialsTransport{opts: opts, next: http.DefaultTransport}}
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"name":"test"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the retry with the new token to succeed, got %d", resp.StatusCode)
+	}
+	token, err := opts.Credentials().Token()
+	if err != nil || token != "new-token" {
+		t.Errorf("expected the credentials of the options to hold the new token, got %q, %v", token, err)
+	}
+	if len(bodies) != 2 || bodies[1] != `{"name":"test"}` {
+		t.Errorf("expected the request body to be sent again, got %q", bodies)
+	}
+}
+
+func TestCredentialsTransportInvalidToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	opts, err := types.ParseTargetOptions(`{"API Token": "invalid-token"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	client := &http.Client{Transport: &credentialsTransport{opts: opts, next: http.DefaultTransport}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+	if requests != 1 {
+		t.Errorf("expected no retry when the token did not change, got %d requests", requests)
+	}
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// GetCostReport returns the cost of all resources created by the provider in the Hetzner project of the API token.
+func GetCostReport(opts *types.TargetOptions) (*types.CostReport, error) {
+	client := newClient(opts)
+
+	pricing, _, err := client.Pricing.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return getCostReport(client, &pricing, ManagedLabelSelector)
+}
+
+// checkBudget refuses the creation of a workspace when it would exceed the monthly spend cap of its budget scope.
+func checkBudget(client *hcloud.Client, workspace *workspace.Workspace, location string, opts *types.TargetOptions, logWriter io.Writer) error {
+	if opts.MaxMonthlySpend <= 0 {
+		return nil
+	}
+
+	pricing, _, err := client.Pricing.Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	labelSelector := fmt.Sprintf("%s,%s=%s", ManagedLabelSelector, TargetLabel, sanitizeLabelValue(workspace.Target))
+	if opts.BudgetLabel != "" {
+		labelSelector = fmt.Sprintf("%s,%s", ManagedLabelSelector, opts.BudgetLabel)
+	}
+
+	report, err := getCostReport(client, &pricing, labelSelector)
+	if err != nil {
+		return err
+	}
+
+	estimate, err := types.EstimateCost(&pricing, types.CostEstimateOpts{
+		Location:       location,
+		ServerType:     opts.ServerType,
+		VolumeSizes:    []int{opts.DiskSize},
+		PrimaryIPTypes: []hcloud.PrimaryIPType{hcloud.PrimaryIPTypeIPv4, hcloud.PrimaryIPTypeIPv6},
+		Backups:        opts.Backups,
+	})
+	if err != nil {
+		return err
+	}
+
+	status, err := types.CheckBudget(report.Total.Monthly, estimate.Total.Monthly, opts)
+	if err != nil {
+		return fmt.Errorf("%w %s", err, estimate.Currency)
+	}
+
+	if status.Warning {
+		logWriter.Write([]byte(fmt.Sprintf("Warning: projected monthly spend of %.2f %s is above %.0f%% of the %.2f %s budget\n",
+			status.Projected, estimate.Currency, opts.BudgetWarningThreshold, status.Cap, estimate.Currency)))
+	}
+
+	return nil
+}
+
+// getCostReport returns the cost of all resources matching the label selector.
+func getCostReport(client *hcloud.Client, pricing *hcloud.Pricing, labelSelector string) (*types.CostReport, error) {
+	report := &types.CostReport{Currency: pricing.Volume.PerGBMonthly.Currency}
+
+	servers, err := client.Server.AllWithOpts(context.Background(), hcloud.ServerListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		estimate, err := types.EstimateServerCost(pricing, server, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		location := server.Datacenter.Location.Name
+		workspaceId := server.Labels[WorkspaceIdLabel]
+
+		report.AddEntry(types.CostReportEntry{
+			ResourceType: "server",
+			ID:           server.ID,
+			Name:         server.Name,
+			WorkspaceId:  workspaceId,
+			Location:     location,
+			Cost: types.Cost{
+				Hourly:  estimate.Server.Hourly + estimate.Backups.Hourly,
+				Monthly: estimate.Server.Monthly + estimate.Backups.Monthly,
+			},
+		})
+
+		primaryIPs := map[hcloud.PrimaryIPType]int{
+			hcloud.PrimaryIPTypeIPv4: server.PublicNet.IPv4.ID,
+			hcloud.PrimaryIPTypeIPv6: server.PublicNet.IPv6.ID,
+		}
+		for _, ipType := range []hcloud.PrimaryIPType{hcloud.PrimaryIPTypeIPv4, hcloud.PrimaryIPTypeIPv6} {
+			if primaryIPs[ipType] == 0 {
+				continue
+			}
+
+			ipCost, err := types.EstimatePrimaryIPCost(pricing, ipType, location)
+			if err != nil {
+				return nil, err
+			}
+
+			report.AddEntry(types.CostReportEntry{
+				ResourceType: "primary_ip",
+				ID:           primaryIPs[ipType],
+				Name:         string(ipType),
+				WorkspaceId:  workspaceId,
+				Location:     location,
+				Cost:         ipCost,
+			})
+		}
+	}
+
+	volumes, err := client.Volume.AllWithOpts(context.Background(), hcloud.VolumeListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		volumeCost, err := types.EstimateVolumeCost(pricing, volume.Size)
+		if err != nil {
+			return nil, err
+		}
+
+		location := ""
+		if volume.Location != nil {
+			location = volume.Location.Name
+		}
+
+		report.AddEntry(types.CostReportEntry{
+			ResourceType: "volume",
+			ID:           volume.ID,
+			Name:         volume.Name,
+			WorkspaceId:  volume.Labels[WorkspaceIdLabel],
+			Location:     location,
+			Cost:         volumeCost,
+		})
+	}
+
+	return report, nil
+}
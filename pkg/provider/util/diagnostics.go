@@ -0,0 +1,90 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+	"github.com/hetznercloud/hcloud-go/hcloud/schema"
+)
+
+const diagnosticsActionCount = 10
+
+// WriteServerDiagnostics writes the status and the most recent actions of the workspace server to the log writer.
+// It returns the server so that callers can reach it for further diagnostics.
+func WriteServerDiagnostics(workspace *workspace.Workspace, opts *types.TargetOptions, logWriter io.Writer) (*hcloud.Server, error) {
+	client := newClient(opts)
+
+	server, err := GetServer(workspace, opts)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		logWriter.Write([]byte("Server not found\n"))
+		return nil, nil
+	}
+
+	logWriter.Write([]byte(fmt.Sprintf("Server %s (%d) status: %s, created %s\n", server.Name, server.ID, server.Status, server.Created.Format(time.RFC3339))))
+
+	actions, err := listServerActions(client, server, diagnosticsActionCount)
+	if err != nil {
+		return server, err
+	}
+
+	for _, action := range actions {
+		line := fmt.Sprintf("Action %s (%d): %s, started %s", action.Command, action.ID, action.Status, action.Started.Format(time.RFC3339))
+		if action.ErrorMessage != "" {
+			line += fmt.Sprintf(", error %s: %s", action.ErrorCode, action.ErrorMessage)
+		}
+		logWriter.Write([]byte(line + "\n"))
+	}
+
+	return server, nil
+}
+
+// DiagnosticsSshKeyName returns the name of the Hetzner SSH key used to reach a workspace server for diagnostics.
+func DiagnosticsSshKeyName(workspaceId string) string {
+	return fmt.Sprintf("daytona-%s-diagnostics", workspaceId)
+}
+
+// listServerActions returns the most recent actions of the server, newest first. hcloud-go only lists the actions
+// of all servers, so the server-scoped endpoint is requested directly and paged until count actions are found.
+func listServerActions(client *hcloud.Client, server *hcloud.Server, count int) ([]*hcloud.Action, error) {
+	actions := []*hcloud.Action{}
+
+	for page := 1; page != 0 && len(actions) < count; {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(count))
+		query.Set("sort", "id:desc")
+
+		req, err := client.NewRequest(context.Background(), "GET", fmt.Sprintf("/servers/%d/actions?%s", server.ID, query.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var body schema.ActionListResponse
+		resp, err := client.Do(req, &body)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range body.Actions {
+			if len(actions) < count {
+				actions = append(actions, hcloud.ActionFromSchema(action))
+			}
+		}
+
+		page = 0
+		if resp.Meta.Pagination != nil {
+			page = resp.Meta.Pagination.NextPage
+		}
+	}
+
+	return actions, nil
+}
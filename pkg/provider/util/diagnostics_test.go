@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func TestListServerActions(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		page := r.URL.Query().Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		switch page {
+		case "1":
+			fmt.Fprint(w, `{"actions": [{"id": 3, "command": "start_server"}, {"id": 2, "command": "create_server"}],
+				"meta": {"pagination": {"page": 1, "per_page": 3, "next_page": 2}}}`)
+		case "2":
+			fmt.Fprint(w, `{"actions": [{"id": 1, "command": "attach_volume"}, {"id": 0, "command": "unused"}],
+				"meta": {"pagination": {"page": 2, "per_page": 3, "next_page": 3}}}`)
+		default:
+			t.Errorf("unexpected request of page %s", page)
+		}
+	}))
+	defer server.Close()
+
+	client := hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token"))
+	actions, err := listServerActions(client, &hcloud.Server{ID: 42}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(actions) != 3 || actions[0].ID != 3 || actions[2].ID != 1 {
+		t.Errorf("expected the 3 most recent actions, got %v", actions)
+	}
+	for _, path := range paths {
+		if path != "/servers/42/actions" {
+			t.Errorf("expected the actions of the server to be requested, got %s", path)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("expected paging to stop once enough actions were found, got %d requests", len(paths))
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -13,33 +14,36 @@ import (
 	"github.com/hetznercloud/hcloud-go/hcloud"
 )
 
-func CreateWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions, initScript string, logWriter io.Writer) error {
-	envVars := workspace.EnvVars
-	envVars["DAYTONA_AGENT_LOG_FILE_PATH"] = "/home/daytona/.daytona-agent.log"
+const (
+	// ManagedByLabel marks every resource created by the provider.
+	ManagedByLabel = "daytona.io/managed-by"
+	ManagedByValue = "daytona-provider-hetzner"
+	// WorkspaceIdLabel holds the id of the workspace that owns a resource.
+	WorkspaceIdLabel = "daytona.io/workspace-id"
+	// TargetLabel holds the sanitized name of the target the workspace was created with.
+	TargetLabel = "daytona.io/target"
+
+	// AgentLogFilePath is the path of the Daytona agent log on the workspace server.
+	AgentLogFilePath = "/home/daytona/.daytona-agent.log"
+)
 
-	customData := `#!/bin/bash
-useradd -m -d /home/daytona daytona
+// ManagedLabelSelector selects all resources created by the provider.
+var ManagedLabelSelector = fmt.Sprintf("%s=%s", ManagedByLabel, ManagedByValue)
 
-curl -fsSL https://get.docker.com | bash
+// CreateWorkspace creates the server and volume of a workspace. If diagnosticsPublicKey is set, it is
+// registered as an SSH key for root so that boot failures can be diagnosed outside the tailnet.
+func CreateWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions, initScript, diagnosticsPublicKey string, logWriter io.Writer) error {
+	envVars := workspace.EnvVars
+	envVars["DAYTONA_AGENT_LOG_FILE_PATH"] = AgentLogFilePath
 
-# Modify Docker daemon configuration
-cat > /etc/docker/daemon.json <<EOF
-{
-  "hosts": ["unix:///var/run/docker.sock", "tcp://127.0.0.1:2375"]
-}
-EOF
+	var setupScript, agentScript string
 
-# Create a systemd drop-in file to modify the Docker service
-mkdir -p /etc/systemd/system/docker.service.d
-cat > /etc/systemd/system/docker.service.d/override.conf <<EOF
-[Service]
-ExecStart=
-ExecStart=/usr/bin/dockerd
-EOF
+	// Restored images already contain the daytona user, Docker and the Daytona agent.
+	if opts.RestoreImage == "" {
+		setupScript = `useradd -m -d /home/daytona daytona
 
-systemctl daemon-reload
-systemctl restart docker
-systemctl start docker
+curl -fsSL https://get.docker.com | bash
+systemctl enable --now docker
 
 usermod -aG docker daytona
 
@@ -53,11 +57,13 @@ echo "daytona ALL=(ALL) NOPASSWD:ALL" > /etc/sudoers.d/91-daytona
 
 `
 
-	for k, v := range envVars {
-		customData += fmt.Sprintf("export %s=%s\n", k, v)
+		for k, v := range envVars {
+			agentScript += fmt.Sprintf("export %s=%s\n", k, v)
+		}
+		agentScript += initScript
 	}
-	customData += initScript
-	customData += `
+
+	agentScript += `
 echo '[Unit]
 Description=Daytona Agent Service
 After=network.target
@@ -69,21 +75,21 @@ Restart=always
 `
 
 	for k, v := range envVars {
-		customData += fmt.Sprintf("Environment='%s=%s'\n", k, v)
+		agentScript += fmt.Sprintf("Environment='%s=%s'\n", k, v)
 	}
 
-	customData += `
+	agentScript += `
 [Install]
 WantedBy=multi-user.target' > /etc/systemd/system/daytona-agent.service
 systemctl daemon-reload
 systemctl enable daytona-agent.service
-systemctl start daytona-agent.service
+systemctl restart daytona-agent.service
 `
-	return createServer(workspace.Id, customData, opts, logWriter)
+	return createServer(workspace, setupScript, agentScript, diagnosticsPublicKey, opts, logWriter)
 }
 
 func StartWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions) error {
-	client := hcloud.NewClient(hcloud.WithToken(opts.APIToken))
+	client := newClient(opts)
 
 	server, err := GetServer(workspace, opts)
 	if err != nil {
@@ -98,12 +104,13 @@ func StartWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions) e
 	if err != nil {
 		return err
 	}
+	logger.Debug("powering on server", "workspace_id", workspace.Id, "server_id", server.ID, "hetzner_action_id", action.ID)
 
 	return action.Error()
 }
 
 func StopWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions) error {
-	client := hcloud.NewClient(hcloud.WithToken(opts.APIToken))
+	client := newClient(opts)
 
 	server, err := GetServer(workspace, opts)
 	if err != nil {
@@ -118,94 +125,376 @@ func StopWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions) er
 	if err != nil {
 		return err
 	}
+	logger.Debug("powering off server", "workspace_id", workspace.Id, "server_id", server.ID, "hetzner_action_id", action.ID)
 
 	return action.Error()
 }
 
-func DeleteWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions) error {
-	client := hcloud.NewClient(hcloud.WithToken(opts.APIToken))
+// DeleteWorkspace deletes the servers, volumes and SSH key of a workspace. Each resource is found and deleted
+// on its own, so that a partially deleted workspace can be deleted again, and the errors of all steps are
+// returned together. A protected workspace is only deleted when force is set, after its protection is removed.
+// With the Snapshot On Destroy option, a snapshot is taken before anything is deleted.
+func DeleteWorkspace(workspace *workspace.Workspace, opts *types.TargetOptions, force bool, logWriter io.Writer) error {
+	client := newClient(opts)
 
-	server, err := GetServer(workspace, opts)
+	resources, err := findWorkspaceResources(client, workspace.Id)
 	if err != nil {
 		return err
 	}
 
-	result, _, err := client.Server.DeleteWithResult(context.Background(), server)
-	if err != nil {
-		return err
+	protected := isProtected(resources.servers, resources.volumes)
+	if protected && !force {
+		return fmt.Errorf("%w: add %s to the Force Delete option of the target to delete workspace %s", ErrWorkspaceProtected, workspace.Id, workspace.Id)
 	}
 
-	err = waitForAction(client, result.Action)
-	if err != nil {
-		return err
+	// The snapshot is taken while the workspace is still protected, so that it stays protected if it fails.
+	if opts.SnapshotOnDestroy && len(resources.servers) > 0 {
+		_, err = CreateSnapshot(workspace, opts, types.SnapshotReasonDestroy, logWriter)
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
 	}
 
-	for _, volume := range server.Volumes {
-		_, err = client.Volume.Delete(context.Background(), volume)
+	if protected {
+		logWriter.Write([]byte("Removing the protection of the workspace\n"))
+		err = retryTeardown("workspace protection", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), teardownActionTimeout)
+			defer cancel()
+			return setProtection(ctx, client, resources.servers, resources.volumes, false)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
-	return result.Action.Error()
+	var errs []error
+	for _, server := range resources.servers {
+		// Volumes that were attached with the Attach Volumes option belong to the user and are only detached.
+		for _, volume := range server.Volumes {
+			if isWorkspaceVolume(resources.volumes, volume) {
+				continue
+			}
+			err = retryTeardown(fmt.Sprintf("volume %d attachment", volume.ID), func() error {
+				return detachVolume(client, volume)
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		err = deleteServer(client, server)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		err = deleteEmptyPlacementGroup(client, server.PlacementGroup)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete placement group: %w", err))
+		}
+	}
+
+	for _, volume := range resources.volumes {
+		if opts.SnapshotOnDestroy {
+			// The snapshot only contains the root disk, so the volume is kept for the Restore Image option.
+			err = retryTeardown(fmt.Sprintf("volume %s attachment", volume.Name), func() error {
+				return detachVolume(client, volume)
+			})
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			logWriter.Write([]byte(fmt.Sprintf("Kept volume %s for restoring the workspace\n", volume.Name)))
+			continue
+		}
+
+		err = deleteVolume(client, volume)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if resources.sshKey != nil {
+		err = deleteSSHKey(client, resources.sshKey)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-// createServer creates a new Hetzner server and volume.
-func createServer(workspaceId, customData string, opts *types.TargetOptions, logWriter io.Writer) error {
-	client := hcloud.NewClient(hcloud.WithToken(opts.APIToken))
+// createServer creates a new Hetzner server and volume. With the Restore Image option, the server boots from
+// that image and the volume that was kept when the original workspace was deleted is reattached. The volumes of
+// the Attach Volumes option are attached without automount once the server is created. The boot script runs
+// setupScript, which creates the daytona user, then mounts the attached volumes and runs agentScript, which
+// installs and starts the agent.
+func createServer(workspace *workspace.Workspace, setupScript, agentScript, diagnosticsPublicKey string, opts *types.TargetOptions, logWriter io.Writer) (err error) {
+	client := newClient(opts)
+	workspaceId := workspace.Id
 
-	location, _, err := client.Location.GetByName(context.Background(), opts.Location)
+	labels, err := workspaceLabels(workspace, opts)
 	if err != nil {
 		return err
 	}
 
-	spinner := logwriters.ShowSpinner(logWriter, "Creating Hetzner volume", "Hetzner volume created")
-	volume, _, err := client.Volume.Create(context.Background(), hcloud.VolumeCreateOpts{
-		Location: location,
-		Name:     fmt.Sprintf("daytona-%s", workspaceId),
-		Size:     opts.DiskSize,
-		Format:   hcloud.Ptr("ext4"),
-	})
+	serverType, _, err := client.ServerType.GetByName(context.Background(), opts.ServerType)
 	if err != nil {
 		return err
 	}
-	close(spinner)
-
-	spinner = logwriters.ShowSpinner(logWriter, "Creating Hetzner server", "Hetzner server created")
-	defer close(spinner)
+	if serverType == nil {
+		return fmt.Errorf("server type %s not found", opts.ServerType)
+	}
 
-	serverType, _, err := client.ServerType.GetByName(context.Background(), opts.ServerType)
+	location, err := selectLocation(client, serverType, opts, logWriter)
 	if err != nil {
 		return err
 	}
 
+	// The resources created by this call are deleted again, in reverse order, if a later step fails.
+	var rollback []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(rollback) - 1; i >= 0; i-- {
+			rollbackErr := rollback[i]()
+			if rollbackErr != nil {
+				logWriter.Write([]byte("Failed to clean up after the failed creation: " + rollbackErr.Error() + "\n"))
+			}
+		}
+	}()
+
 	vmArch := hcloud.ArchitectureX86
 	if strings.HasPrefix(opts.ServerType, "cax") {
 		// Server types with cax prefix are Arm64 architecture
 		vmArch = hcloud.ArchitectureARM
 	}
 
-	image, _, err := client.Image.GetByNameAndArchitecture(context.Background(), opts.DiskImage, vmArch)
+	var image, restoreImage *hcloud.Image
+	var volume *hcloud.Volume
+	if opts.RestoreImage != "" {
+		restoreImage, err = getRestoreImage(client, opts.RestoreImage, vmArch)
+		if err != nil {
+			return err
+		}
+		logWriter.Write([]byte(fmt.Sprintf("Restoring the workspace from image %d\n", restoreImage.ID)))
+
+		volume, err = getRestoreVolume(client, restoreImage, workspaceId, labels, location, logWriter)
+		if err != nil {
+			return err
+		}
+		if volume != nil {
+			logWriter.Write([]byte(fmt.Sprintf("Reattaching volume %s\n", volume.Name)))
+			rollback = append(rollback, func() error {
+				return releaseRestoreVolume(client, volume, restoreImage)
+			})
+		}
+	}
+
+	// A reattached volume already counts towards the volume limit.
+	newVolumes := 1
+	if volume != nil {
+		newVolumes = 0
+	}
+	err = checkProjectLimits(client, serverType, newVolumes, opts)
+	if err != nil {
+		return err
+	}
+
+	err = checkBudget(client, workspace, location.Name, opts, logWriter)
+	if err != nil {
+		return err
+	}
+
+	placementGroup, err := getPlacementGroup(client, workspace, opts, logWriter)
 	if err != nil {
 		return err
 	}
+	// Only an empty group that the provider manages is deleted, so a group that was not created here is kept
+	// as long as it has servers.
+	rollback = append(rollback, func() error {
+		return deleteEmptyPlacementGroup(client, placementGroup)
+	})
+
+	volumeMounts, err := getVolumeMounts(client, opts, location)
+	if err != nil {
+		return err
+	}
+
+	progress := logwriters.NewProgress(logWriter, logwriters.DefaultProgressMode())
+
+	if volume == nil {
+		step := progress.Start("Creating Hetzner volume")
+		defer step.Stop()
+		result, _, err := client.Volume.Create(context.Background(), hcloud.VolumeCreateOpts{
+			Location: location,
+			Name:     fmt.Sprintf("daytona-%s", workspaceId),
+			Size:     opts.DiskSize,
+			Format:   hcloud.Ptr("ext4"),
+			Labels:   labels,
+		})
+		if err != nil {
+			err = wrapLimitError("volume", err)
+			step.Fail(err)
+			return err
+		}
+		step.Finish("Hetzner volume created")
+		logger.Debug("created volume", "workspace_id", workspaceId, "volume_id", result.Volume.ID)
+		volume = result.Volume
+		rollback = append(rollback, func() error {
+			return deleteVolume(client, volume)
+		})
+	}
+
+	step := progress.Start("Creating Hetzner server")
+	defer func() {
+		if err != nil {
+			step.Fail(err)
+		} else {
+			step.Finish("Hetzner server created")
+		}
+	}()
+
+	if restoreImage != nil {
+		image = restoreImage
+	} else {
+		image, _, err = client.Image.GetByNameAndArchitecture(context.Background(), opts.DiskImage, vmArch)
+		if err != nil {
+			return err
+		}
+	}
 
-	_, _, err = client.Server.Create(context.Background(), hcloud.ServerCreateOpts{
+	var sshKeys []*hcloud.SSHKey
+	if diagnosticsPublicKey != "" {
+		sshKey, _, err := client.SSHKey.Create(context.Background(), hcloud.SSHKeyCreateOpts{
+			Name:      DiagnosticsSshKeyName(workspaceId),
+			PublicKey: diagnosticsPublicKey,
+			Labels:    labels,
+		})
+		if err != nil {
+			return wrapLimitError("SSH key", err)
+		}
+		sshKeys = append(sshKeys, sshKey)
+		rollback = append(rollback, func() error {
+			return deleteSSHKey(client, sshKey)
+		})
+	}
+
+	result, _, err := client.Server.Create(context.Background(), hcloud.ServerCreateOpts{
 		Name:             fmt.Sprintf("daytona-%s", workspaceId),
 		ServerType:       serverType,
 		Image:            image,
 		Location:         location,
-		UserData:         customData,
+		UserData:         "#!/bin/bash\n" + setupScript + volumeMountScript(volumeMounts) + agentScript,
 		StartAfterCreate: hcloud.Ptr(true),
 		Automount:        hcloud.Ptr(true),
-		Volumes:          []*hcloud.Volume{volume.Volume},
+		Volumes:          []*hcloud.Volume{volume},
+		SSHKeys:          sshKeys,
+		Labels:           labels,
+		PlacementGroup:   placementGroup,
 	})
-	return err
+	if err != nil {
+		return wrapLimitError("server", err)
+	}
+	logger.Debug("creating server", "workspace_id", workspaceId, "server_id", result.Server.ID, "hetzner_action_id", result.Action.ID)
+	rollback = append(rollback, func() error {
+		return deleteServer(client, result.Server)
+	})
+
+	labelPrimaryIPs(client, result.Server, labels, logWriter)
+
+	if len(volumeMounts) > 0 || opts.Backups || opts.Protected {
+		// The server is locked until it is created, so volumes, backups and protection can only be added afterwards.
+		err = waitForAction(context.Background(), client, result.Action)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = attachVolumes(client, result.Server, volumeMounts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Backups {
+		err = enableBackups(client, result.Server)
+		if err != nil {
+			return fmt.Errorf("failed to enable backups: %w", err)
+		}
+	}
+
+	if opts.Protected {
+		err = setProtection(context.Background(), client, []*hcloud.Server{result.Server}, []*hcloud.Volume{volume}, true)
+		if err != nil {
+			return fmt.Errorf("failed to enable protection: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// labelPrimaryIPs adds the workspace labels to the primary IPs that Hetzner created along with the server.
+// Failing to label them does not fail the workspace creation.
+func labelPrimaryIPs(client *hcloud.Client, server *hcloud.Server, labels map[string]string, logWriter io.Writer) {
+	for _, primaryIPId := range []int{server.PublicNet.IPv4.ID, server.PublicNet.IPv6.ID} {
+		if primaryIPId == 0 {
+			continue
+		}
+
+		_, _, err := client.PrimaryIP.Update(context.Background(), &hcloud.PrimaryIP{ID: primaryIPId}, hcloud.PrimaryIPUpdateOpts{
+			Labels: &labels,
+		})
+		if err != nil {
+			logWriter.Write([]byte(fmt.Sprintf("Failed to label primary IP %d: %s\n", primaryIPId, err)))
+		}
+	}
+}
+
+// workspaceLabels returns the labels for resources of the given workspace: the Labels option, the budget label
+// and the ownership labels.
+func workspaceLabels(workspace *workspace.Workspace, opts *types.TargetOptions) (map[string]string, error) {
+	labels, err := opts.LabelMap()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.BudgetLabel != "" {
+		key, value, err := types.ParseLabel(opts.BudgetLabel)
+		if err != nil {
+			return nil, err
+		}
+		labels[key] = value
+	}
+
+	labels[ManagedByLabel] = ManagedByValue
+	labels[WorkspaceIdLabel] = workspace.Id
+	labels[TargetLabel] = sanitizeLabelValue(workspace.Target)
+
+	return labels, nil
+}
+
+// sanitizeLabelValue converts a value into a valid Hetzner label value by replacing unsupported characters.
+func sanitizeLabelValue(value string) string {
+	sanitized := []rune{}
+	for _, r := range value {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			sanitized = append(sanitized, r)
+		} else {
+			sanitized = append(sanitized, '-')
+		}
+	}
+
+	if len(sanitized) > 63 {
+		sanitized = sanitized[:63]
+	}
+
+	return strings.Trim(string(sanitized), "-_.")
 }
 
 // GetServer returns the virtual machine instance for the given workspace.
 func GetServer(workspace *workspace.Workspace, opts *types.TargetOptions) (*hcloud.Server, error) {
-	client := hcloud.NewClient(hcloud.WithToken(opts.APIToken))
+	client := newClient(opts)
 	server, _, s := client.Server.GetByName(context.Background(), fmt.Sprintf("daytona-%s", workspace.Id))
 	if s != nil {
 		return nil, s
@@ -213,22 +502,60 @@ func GetServer(workspace *workspace.Workspace, opts *types.TargetOptions) (*hclo
 	return server, nil
 }
 
-// waitForAction waits for the action to complete.
-func waitForAction(client *hcloud.Client, action *hcloud.Action) error {
+// GetServerVolumes returns the volumes attached to the given server.
+func GetServerVolumes(server *hcloud.Server, opts *types.TargetOptions) ([]*hcloud.Volume, error) {
+	client := newClient(opts)
+
+	var volumes []*hcloud.Volume
+	for _, serverVolume := range server.Volumes {
+		volume, _, err := client.Volume.GetByID(context.Background(), serverVolume.ID)
+		if err != nil {
+			return nil, err
+		}
+		if volume != nil {
+			volumes = append(volumes, volume)
+		}
+	}
+
+	return volumes, nil
+}
+
+// GetPricing returns the current Hetzner Cloud prices.
+func GetPricing(opts *types.TargetOptions) (*hcloud.Pricing, error) {
+	client := newClient(opts)
+	pricing, _, err := client.Pricing.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+// actionPollInterval is the time between two checks of a running action. It is a variable for the tests.
+var actionPollInterval = 2 * time.Second
+
+// waitForAction waits for the action to complete, or until the context is done.
+func waitForAction(ctx context.Context, client *hcloud.Client, action *hcloud.Action) error {
 	for {
-		action, _, err := client.Action.GetByID(context.Background(), action.ID)
+		action, _, err := client.Action.GetByID(ctx, action.ID)
 		if err != nil {
 			return err
 		}
 
 		if action.Status == hcloud.ActionStatusSuccess {
+			logger.Debug("hetzner action finished", "hetzner_action_id", action.ID, "command", action.Command)
 			return nil
 		}
 
 		if action.Status == hcloud.ActionStatusError {
+			logger.Warn("hetzner action failed", "hetzner_action_id", action.ID, "command", action.Command, "error", action.ErrorMessage)
 			return action.Error()
 		}
 
-		time.Sleep(2 * time.Second)
+		select {
+		case <-ctx.Done():
+			logger.Warn("hetzner action did not finish in time", "hetzner_action_id", action.ID, "command", action.Command)
+			return fmt.Errorf("action %s (%d) did not finish: %w", action.Command, action.ID, ctx.Err())
+		case <-time.After(actionPollInterval):
+		}
 	}
 }
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+)
+
+func TestCreateServerRollsBackOnFailure(t *testing.T) {
+	api := newFakeApi(t, map[string]fakeResponse{
+		"GET /server_types": {http.StatusOK, `{"server_types": [{"id": 1, "name": "cx22", "cores": 2}]}`},
+		"GET /datacenters": {http.StatusOK, `{"datacenters": [{"id": 1, "name": "fsn1-dc14", "location": {"name": "fsn1"},
+			"server_types": {"available": [1], "supported": [1], "available_for_migration": [1]}}]}`},
+		"GET /locations":     {http.StatusOK, `{"locations": [{"id": 1, "name": "fsn1"}]}`},
+		"GET /images":        {http.StatusOK, `{"images": [{"id": 5, "name": "ubuntu-22.04", "type": "system", "architecture": "x86"}]}`},
+		"POST /volumes":      {http.StatusCreated, `{"volume": {"id": 7, "name": "daytona-ws", "location": {"name": "fsn1"}}, "action": {"id": 1, "status": "success"}}`},
+		"GET /volumes/7":     {http.StatusOK, `{"volume": {"id": 7, "name": "daytona-ws", "server": null, "location": {"name": "fsn1"}}}`},
+		"DELETE /volumes/7":  {http.StatusNoContent, deletedContent},
+		"POST /ssh_keys":     {http.StatusCreated, `{"ssh_key": {"id": 3, "name": "daytona-ws-diagnostics"}}`},
+		"DELETE /ssh_keys/3": {http.StatusNoContent, deletedContent},
+		"POST /servers":      {http.StatusForbidden, `{"error": {"code": "resource_limit_exceeded", "message": "server limit exceeded"}}`},
+	})
+
+	opts := &types.TargetOptions{APIToken: "token", Location: "fsn1", ServerType: "cx22", DiskImage: "ubuntu-22.04", DiskSize: 20}
+	err := createServer(&workspace.Workspace{Id: "ws", Target: "default"}, "", "", "ssh-ed25519 AAAA", opts, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("expected the server creation to fail")
+	}
+
+	deleteSshKey := api.index("DELETE /ssh_keys/3")
+	deleteVolume := api.index("DELETE /volumes/7")
+	if deleteSshKey == -1 || deleteVolume == -1 {
+		t.Fatalf("expected the SSH key and the volume to be deleted, got %v", api.requests)
+	}
+	if deleteVolume < deleteSshKey {
+		t.Errorf("expected the resources to be deleted in reverse order, got %v", api.requests)
+	}
+}
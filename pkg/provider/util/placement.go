@@ -0,0 +1,100 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// PlacementGroupLabel marks the spread placement groups that the provider manages for a target.
+const PlacementGroupLabel = "daytona.io/placement-group"
+
+// getPlacementGroup returns the spread placement group for a new workspace server, or nil if the target does
+// not use one. Managed groups are created on demand, with a new group once all existing ones are full.
+func getPlacementGroup(client *hcloud.Client, workspace *workspace.Workspace, opts *types.TargetOptions, logWriter io.Writer) (*hcloud.PlacementGroup, error) {
+	if opts.PlacementGroup != "" {
+		group, _, err := client.PlacementGroup.GetByName(context.Background(), opts.PlacementGroup)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			return nil, fmt.Errorf("placement group %s not found", opts.PlacementGroup)
+		}
+		if len(group.Servers) >= types.MaxPlacementGroupServers {
+			return nil, fmt.Errorf("placement group %s already has %d servers, the Hetzner limit", group.Name, types.MaxPlacementGroupServers)
+		}
+		return group, nil
+	}
+
+	if !opts.SpreadServers {
+		return nil, nil
+	}
+
+	target := sanitizeLabelValue(workspace.Target)
+	groups, err := client.PlacementGroup.AllWithOpts(context.Background(), hcloud.PlacementGroupListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: fmt.Sprintf("%s,%s=%s,%s", ManagedLabelSelector, TargetLabel, target, PlacementGroupLabel),
+		},
+		Type: hcloud.PlacementGroupTypeSpread,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	group := types.SelectPlacementGroup(groups)
+	if group != nil {
+		return group, nil
+	}
+
+	labels, err := opts.LabelMap()
+	if err != nil {
+		return nil, err
+	}
+	labels[ManagedByLabel] = ManagedByValue
+	labels[TargetLabel] = target
+	labels[PlacementGroupLabel] = "spread"
+
+	result, _, err := client.PlacementGroup.Create(context.Background(), hcloud.PlacementGroupCreateOpts{
+		Name:   types.NextPlacementGroupName(target, groups),
+		Labels: labels,
+		Type:   hcloud.PlacementGroupTypeSpread,
+	})
+	if err != nil {
+		return nil, wrapLimitError("placement group", err)
+	}
+	logWriter.Write([]byte(fmt.Sprintf("Created spread placement group %s\n", result.PlacementGroup.Name)))
+	logger.Debug("created placement group", "workspace_id", workspace.Id, "placement_group_id", result.PlacementGroup.ID)
+
+	return result.PlacementGroup, nil
+}
+
+// deleteEmptyPlacementGroup deletes the placement group of a deleted server if the provider manages it and
+// it has no servers left. Placement groups that were created outside the provider are kept.
+func deleteEmptyPlacementGroup(client *hcloud.Client, placementGroup *hcloud.PlacementGroup) error {
+	if placementGroup == nil {
+		return nil
+	}
+
+	group, _, err := client.PlacementGroup.GetByID(context.Background(), placementGroup.ID)
+	if err != nil {
+		return err
+	}
+	if group == nil || group.Labels[ManagedByLabel] != ManagedByValue || group.Labels[PlacementGroupLabel] == "" {
+		return nil
+	}
+	if len(group.Servers) > 0 {
+		return nil
+	}
+
+	_, err = client.PlacementGroup.Delete(context.Background(), group)
+	if err != nil {
+		return err
+	}
+	logger.Debug("deleted empty placement group", "placement_group_id", group.ID)
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// presetCatalog holds the server types and datacenters visible to an API token.
+type presetCatalog struct {
+	serverTypes map[string]*hcloud.ServerType
+	datacenters []*hcloud.Datacenter
+	err         error
+}
+
+// FilterPresets removes the presets whose server type cannot currently be created in their location with
+// their API token. Presets without a token are kept, since the token is only known once the target is used.
+func FilterPresets(presets []types.Preset) []types.Preset {
+	catalogs := map[string]*presetCatalog{}
+
+	var filtered []types.Preset
+	for _, preset := range presets {
+		presetOptions, err := preset.TargetOptions()
+		if err != nil {
+			logger.Warn("skipping preset target", "preset", preset.Name, "error", err)
+			continue
+		}
+
+		optionsJson, err := json.Marshal(preset.Options)
+		if err != nil {
+			logger.Warn("skipping preset target", "preset", preset.Name, "error", err)
+			continue
+		}
+		opts, err := types.ParseTargetOptions(string(optionsJson))
+		if errors.Is(err, types.ErrTokenNotSet) {
+			filtered = append(filtered, preset)
+			continue
+		}
+		if err != nil {
+			logger.Warn("skipping preset target", "preset", preset.Name, "error", err)
+			continue
+		}
+
+		catalog, ok := catalogs[opts.APIToken]
+		if !ok {
+			catalog = getPresetCatalog(opts)
+			catalogs[opts.APIToken] = catalog
+		}
+
+		if catalog.err != nil {
+			if hcloud.IsError(catalog.err, hcloud.ErrorCodeUnauthorized) {
+				logger.Warn("skipping preset target", "preset", preset.Name, "error", catalog.err)
+				continue
+			}
+			// The availability cannot be checked, e.g. when offline. Keep the preset rather than losing it.
+			filtered = append(filtered, preset)
+			continue
+		}
+
+		serverType, ok := catalog.serverTypes[presetOptions.ServerType]
+		if !ok {
+			logger.Info("skipping preset target, server type not found", "preset", preset.Name, "server_type", presetOptions.ServerType)
+			continue
+		}
+
+		location := &hcloud.Location{Name: presetOptions.Location}
+		if !isServerTypeAvailable(catalog.datacenters, serverType, location) {
+			logger.Info("skipping preset target, server type unavailable in location", "preset", preset.Name, "server_type", serverType.Name, "location", location.Name)
+			continue
+		}
+
+		filtered = append(filtered, preset)
+	}
+
+	return filtered
+}
+
+func getPresetCatalog(opts *types.TargetOptions) *presetCatalog {
+	client := newClient(opts)
+
+	serverTypes, err := client.ServerType.All(context.Background())
+	if err != nil {
+		return &presetCatalog{err: err}
+	}
+
+	datacenters, err := client.Datacenter.All(context.Background())
+	if err != nil {
+		return &presetCatalog{err: err}
+	}
+
+	catalog := &presetCatalog{
+		serverTypes: map[string]*hcloud.ServerType{},
+		datacenters: datacenters,
+	}
+	for _, serverType := range serverTypes {
+		catalog.serverTypes[serverType.Name] = serverType
+	}
+
+	return catalog
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// ErrWorkspaceProtected is returned when a protected workspace is deleted without the Force Delete option.
+var ErrWorkspaceProtected = errors.New("workspace is protected against deletion")
+
+// setProtection enables or disables the delete and rebuild protection of the servers and the delete
+// protection of the volumes.
+func setProtection(ctx context.Context, client *hcloud.Client, servers []*hcloud.Server, volumes []*hcloud.Volume, protected bool) error {
+	for _, server := range servers {
+		action, _, err := client.Server.ChangeProtection(ctx, server, hcloud.ServerChangeProtectionOpts{
+			Delete:  hcloud.Ptr(protected),
+			Rebuild: hcloud.Ptr(protected),
+		})
+		if err != nil {
+			return err
+		}
+		logger.Debug("changing server protection", "server_id", server.ID, "protected", protected, "hetzner_action_id", action.ID)
+
+		err = waitForAction(ctx, client, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, volume := range volumes {
+		action, _, err := client.Volume.ChangeProtection(ctx, volume, hcloud.VolumeChangeProtectionOpts{
+			Delete: hcloud.Ptr(protected),
+		})
+		if err != nil {
+			return err
+		}
+		logger.Debug("changing volume protection", "volume_id", volume.ID, "protected", protected, "hetzner_action_id", action.ID)
+
+		err = waitForAction(ctx, client, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isProtected returns whether any of the servers or volumes is protected against deletion.
+func isProtected(servers []*hcloud.Server, volumes []*hcloud.Volume) bool {
+	for _, server := range servers {
+		if server.Protection.Delete {
+			return true
+		}
+	}
+	for _, volume := range volumes {
+		if volume.Protection.Delete {
+			return true
+		}
+	}
+	return false
+}
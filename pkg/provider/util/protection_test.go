@@ -0,0 +1,46 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+)
+
+func TestDeleteWorkspaceRefusesProtectedWorkspace(t *testing.T) {
+	api := newFakeApi(t, workspaceResponses(true))
+
+	err := DeleteWorkspace(&workspace.Workspace{Id: "ws"}, &types.TargetOptions{APIToken: "token"}, false, &bytes.Buffer{})
+	if !errors.Is(err, ErrWorkspaceProtected) {
+		t.Fatalf("expected %v, got %v", ErrWorkspaceProtected, err)
+	}
+
+	for _, request := range api.requests {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("expected a protected workspace to be left untouched, got %s", request)
+		}
+	}
+}
+
+func TestDeleteWorkspaceForceDeletesProtectedWorkspace(t *testing.T) {
+	api := newFakeApi(t, workspaceResponses(true))
+
+	err := DeleteWorkspace(&workspace.Workspace{Id: "ws"}, &types.TargetOptions{APIToken: "token"}, true, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	unprotectServer := api.index("POST /servers/1/actions/change_protection")
+	unprotectVolume := api.index("POST /volumes/7/actions/change_protection")
+	deleteServer := api.index("DELETE /servers/1")
+	deleteVolume := api.index("DELETE /volumes/7")
+	if unprotectServer == -1 || unprotectVolume == -1 {
+		t.Fatalf("expected the protection to be removed, got %v", api.requests)
+	}
+	if deleteServer < unprotectServer || deleteVolume < unprotectVolume {
+		t.Errorf("expected the protection to be removed before deleting, got %v", api.requests)
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// permissionCheckPublicKey is rejected by the API, so the permission check never creates an SSH key.
+const permissionCheckPublicKey = "invalid"
+
+var ErrReadOnlyToken = errors.New("the API token is read-only, generate a token with Read & Write permission")
+
+// CheckToken verifies that the API token is accepted by the Hetzner API.
+func CheckToken(opts *types.TargetOptions) error {
+	client := newClient(opts)
+
+	_, _, err := client.Location.List(context.Background(), hcloud.LocationListOpts{
+		ListOpts: hcloud.ListOpts{PerPage: 1},
+	})
+	return err
+}
+
+// CheckWritePermission verifies that the API token can create resources. It sends an SSH key that fails
+// validation: read-only tokens are refused before the request is validated.
+func CheckWritePermission(opts *types.TargetOptions) error {
+	client := newClient(opts)
+
+	sshKey, _, err := client.SSHKey.Create(context.Background(), hcloud.SSHKeyCreateOpts{
+		Name:      "daytona-permission-check",
+		PublicKey: permissionCheckPublicKey,
+	})
+	if err == nil {
+		_, err = client.SSHKey.Delete(context.Background(), sshKey)
+		return err
+	}
+
+	switch {
+	case hcloud.IsError(err, hcloud.ErrorCodeInvalidInput):
+		return nil
+	case hcloud.IsError(err, hcloud.ErrorCodeForbidden):
+		return ErrReadOnlyToken
+	default:
+		return fmt.Errorf("failed to check the token permissions: %w", err)
+	}
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+)
+
+func TestCheckWritePermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		code    string
+		wantErr error
+	}{
+		{name: "rejected key means write permission", status: http.StatusBadRequest, code: "invalid_input"},
+		{name: "forbidden means read-only", status: http.StatusForbidden, code: "forbidden", wantErr: ErrReadOnlyToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/ssh_keys" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"error": {"code": %q, "message": "rejected"}}`, tt.code)
+			}))
+			defer server.Close()
+			defer setApiEndpoint(server.URL)()
+
+			err := CheckWritePermission(&types.TargetOptions{APIToken: "token"})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// setApiEndpoint points the Hetzner clients to the given URL and returns a function that restores the endpoint.
+func setApiEndpoint(url string) func() {
+	endpoint := apiEndpoint
+	apiEndpoint = url
+	return func() { apiEndpoint = endpoint }
+}
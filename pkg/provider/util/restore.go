@@ -0,0 +1,105 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// getRestoreImage returns the snapshot or backup image that the Restore Image option refers to, either by ID
+// or as the newest image matching a key=value label selector.
+func getRestoreImage(client *hcloud.Client, restoreImage string, architecture hcloud.Architecture) (*hcloud.Image, error) {
+	var image *hcloud.Image
+	if id, err := strconv.Atoi(restoreImage); err == nil {
+		image, _, err = client.Image.GetByID(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		if image == nil {
+			return nil, fmt.Errorf("restore image %d not found", id)
+		}
+	} else {
+		images, err := client.Image.AllWithOpts(context.Background(), hcloud.ImageListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: restoreImage,
+			},
+			Type:   []hcloud.ImageType{hcloud.ImageTypeSnapshot, hcloud.ImageTypeBackup},
+			Status: []hcloud.ImageStatus{hcloud.ImageStatusAvailable},
+		})
+		if err != nil {
+			return nil, err
+		}
+		image = types.NewestImage(images)
+		if image == nil {
+			return nil, fmt.Errorf("no snapshot or backup image with the label %s found", restoreImage)
+		}
+	}
+
+	if image.Type != hcloud.ImageTypeSnapshot && image.Type != hcloud.ImageTypeBackup {
+		return nil, fmt.Errorf("image %d is a %s image, only snapshot and backup images can be restored", image.ID, image.Type)
+	}
+	if image.Architecture != architecture {
+		return nil, fmt.Errorf("image %d is built for %s and cannot be restored on a %s server type", image.ID, image.Architecture, architecture)
+	}
+
+	return image, nil
+}
+
+// getRestoreVolume returns the volume that was kept when the workspace the image was taken of was deleted, or
+// nil if a new volume has to be created, e.g. because the server is created in another location than the kept
+// volume. The volume is renamed and relabelled so that it belongs to the new workspace.
+func getRestoreVolume(client *hcloud.Client, image *hcloud.Image, workspaceId string, labels map[string]string, location *hcloud.Location, logWriter io.Writer) (*hcloud.Volume, error) {
+	sourceWorkspaceId := image.Labels[WorkspaceIdLabel]
+	if sourceWorkspaceId == "" {
+		return nil, nil
+	}
+
+	volume, _, err := client.Volume.GetByName(context.Background(), fmt.Sprintf("daytona-%s", sourceWorkspaceId))
+	if err != nil {
+		return nil, err
+	}
+	if volume == nil {
+		return nil, nil
+	}
+
+	if volume.Server != nil {
+		return nil, fmt.Errorf("volume %s is still attached to server %d", volume.Name, volume.Server.ID)
+	}
+	if volume.Location == nil || volume.Location.Name != location.Name {
+		// Volumes can only be attached to servers in their location. The kept volume is left untouched.
+		logWriter.Write([]byte(fmt.Sprintf("Volume %s is not in %s, creating a new, empty volume\n", volume.Name, location.Name)))
+		return nil, nil
+	}
+
+	volume, _, err = client.Volume.Update(context.Background(), volume, hcloud.VolumeUpdateOpts{
+		Name:   fmt.Sprintf("daytona-%s", workspaceId),
+		Labels: labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return volume, nil
+}
+
+// releaseRestoreVolume gives a volume that getRestoreVolume took over the name and workspace label of the
+// workspace the image was taken of again, so that the image can still be restored with it.
+func releaseRestoreVolume(client *hcloud.Client, volume *hcloud.Volume, image *hcloud.Image) error {
+	sourceWorkspaceId := image.Labels[WorkspaceIdLabel]
+
+	labels := map[string]string{}
+	for key, value := range volume.Labels {
+		labels[key] = value
+	}
+	labels[WorkspaceIdLabel] = sourceWorkspaceId
+
+	_, _, err := client.Volume.Update(context.Background(), volume, hcloud.VolumeUpdateOpts{
+		Name:   fmt.Sprintf("daytona-%s", sourceWorkspaceId),
+		Labels: labels,
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func TestGetRestoreVolume(t *testing.T) {
+	tests := []struct {
+		name           string
+		volumeLocation string
+		wantVolume     bool
+	}{
+		{name: "kept volume in the location is reused", volumeLocation: "fsn1", wantVolume: true},
+		{name: "kept volume in another location is left untouched", volumeLocation: "nbg1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/volumes":
+					if r.URL.Query().Get("name") != "daytona-source" {
+						t.Errorf("expected the volume of the source workspace to be looked up, got %s", r.URL.RawQuery)
+					}
+					fmt.Fprintf(w, `{"volumes": [{"id": 7, "name": "daytona-source", "location": {"name": %q}}]}`, tt.volumeLocation)
+				case r.Method == http.MethodPut && r.URL.Path == "/volumes/7":
+					updated = true
+					fmt.Fprint(w, `{"volume": {"id": 7, "name": "daytona-target", "location": {"name": "fsn1"}}}`)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token"))
+			image := &hcloud.Image{ID: 1, Labels: map[string]string{WorkspaceIdLabel: "source"}}
+			logWriter := &bytes.Buffer{}
+
+			volume, err := getRestoreVolume(client, image, "target", map[string]string{}, &hcloud.Location{Name: "fsn1"}, logWriter)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if tt.wantVolume && (volume == nil || volume.Name != "daytona-target" || !updated) {
+				t.Errorf("expected the kept volume to be renamed for the new workspace, got %v", volume)
+			}
+			if !tt.wantVolume {
+				if volume != nil || updated {
+					t.Errorf("expected no volume and no update, got %v", volume)
+				}
+				if !strings.Contains(logWriter.String(), "creating a new, empty volume") {
+					t.Errorf("expected a new volume to be announced, got %q", logWriter.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	logwriters "github.com/daytonaio/daytona-provider-hetzner/internal/log"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// CreateSnapshot takes a snapshot of the root disk of the workspace server and prunes the snapshots of the
+// workspace that exceed the retention. The snapshot carries the labels of the server and the reason. Pruning
+// failures are written to the log writer and do not fail the snapshot.
+func CreateSnapshot(workspace *workspace.Workspace, opts *types.TargetOptions, reason string, logWriter io.Writer) (*hcloud.Image, error) {
+	client := newClient(opts)
+
+	server, err := GetServer(workspace, opts)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server of workspace %s not found", workspace.Id)
+	}
+
+	labels := map[string]string{}
+	for key, value := range server.Labels {
+		labels[key] = value
+	}
+	labels[WorkspaceIdLabel] = workspace.Id
+	labels[types.SnapshotReasonLabel] = reason
+
+	step := logwriters.NewProgress(logWriter, logwriters.DefaultProgressMode()).Start("Creating Hetzner snapshot")
+	defer step.Stop()
+
+	result, _, err := client.Server.CreateImage(context.Background(), server, &hcloud.ServerCreateImageOpts{
+		Type:        hcloud.ImageTypeSnapshot,
+		Description: hcloud.Ptr(fmt.Sprintf("daytona-%s %s %s", workspace.Id, reason, time.Now().UTC().Format(time.RFC3339))),
+		Labels:      labels,
+	})
+	if err != nil {
+		step.Fail(err)
+		return nil, err
+	}
+	logger.Debug("creating snapshot", "workspace_id", workspace.Id, "server_id", server.ID, "image_id", result.Image.ID, "hetzner_action_id", result.Action.ID)
+
+	err = waitForAction(context.Background(), client, result.Action)
+	if err != nil {
+		step.Fail(err)
+		return nil, err
+	}
+	step.Finish(fmt.Sprintf("Hetzner snapshot %d created", result.Image.ID))
+
+	// The snapshot exists at this point, so a failed pruning is only reported and retried after the next snapshot.
+	err = PruneSnapshots(workspace.Id, opts, logWriter)
+	if err != nil {
+		logWriter.Write([]byte("Failed to prune snapshots: " + err.Error() + "\n"))
+	}
+
+	return result.Image, nil
+}
+
+// GetWorkspaceSnapshots returns the snapshots taken of the workspace, including those of deleted servers.
+func GetWorkspaceSnapshots(workspaceId string, opts *types.TargetOptions) ([]*hcloud.Image, error) {
+	client := newClient(opts)
+
+	return client.Image.AllWithOpts(context.Background(), hcloud.ImageListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: fmt.Sprintf("%s,%s=%s", ManagedLabelSelector, WorkspaceIdLabel, workspaceId),
+		},
+		Type: []hcloud.ImageType{hcloud.ImageTypeSnapshot},
+	})
+}
+
+// PruneSnapshots deletes the oldest snapshots of the workspace beyond the Snapshot Retention option.
+func PruneSnapshots(workspaceId string, opts *types.TargetOptions, logWriter io.Writer) error {
+	if opts.SnapshotRetention <= 0 {
+		return nil
+	}
+
+	client := newClient(opts)
+
+	snapshots, err := GetWorkspaceSnapshots(workspaceId, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range types.SnapshotsToPrune(snapshots, opts.SnapshotRetention) {
+		_, err = client.Image.Delete(context.Background(), snapshot)
+		if err != nil {
+			return err
+		}
+		logWriter.Write([]byte(fmt.Sprintf("Deleted snapshot %d beyond the retention of %d snapshots\n", snapshot.ID, opts.SnapshotRetention)))
+	}
+
+	return nil
+}
+
+// enableBackups enables the daily Hetzner backups of the server.
+func enableBackups(client *hcloud.Client, server *hcloud.Server) error {
+	action, _, err := client.Server.EnableBackup(context.Background(), server, "")
+	if err != nil {
+		return err
+	}
+	logger.Debug("enabling backups", "server_id", server.ID, "hetzner_action_id", action.ID)
+
+	return waitForAction(context.Background(), client, action)
+}
@@ -0,0 +1,207 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// teardownAttempts is how often a teardown step is tried before its error is reported.
+const teardownAttempts = 5
+
+// teardownActionTimeout is how long a teardown step waits for its Hetzner action. A step whose action does not
+// finish in time is retried. It is a variable for the tests.
+var teardownActionTimeout = 2 * time.Minute
+
+// teardownRetryDelay is multiplied by the attempt number between attempts. It is a variable for the tests.
+var teardownRetryDelay = 2 * time.Second
+
+// workspaceResources are the Hetzner resources of a workspace, found by their labels and names.
+type workspaceResources struct {
+	servers []*hcloud.Server
+	volumes []*hcloud.Volume
+	sshKey  *hcloud.SSHKey
+}
+
+// findWorkspaceResources finds the resources of a workspace independently of each other, so that a volume or
+// SSH key is still found when its server is already gone.
+func findWorkspaceResources(client *hcloud.Client, workspaceId string) (*workspaceResources, error) {
+	name := fmt.Sprintf("daytona-%s", workspaceId)
+	labelSelector := fmt.Sprintf("%s,%s=%s", ManagedLabelSelector, WorkspaceIdLabel, workspaceId)
+	resources := &workspaceResources{}
+
+	servers, err := client.Server.AllWithOpts(context.Background(), hcloud.ServerListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+	})
+	if err != nil {
+		return nil, err
+	}
+	server, _, err := client.Server.GetByName(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	resources.servers = appendServer(servers, server)
+
+	volumes, err := client.Volume.AllWithOpts(context.Background(), hcloud.VolumeListOpts{
+		ListOpts: hcloud.ListOpts{LabelSelector: labelSelector},
+	})
+	if err != nil {
+		return nil, err
+	}
+	volume, _, err := client.Volume.GetByName(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	resources.volumes = appendVolume(volumes, volume)
+
+	resources.sshKey, _, err = client.SSHKey.GetByName(context.Background(), DiagnosticsSshKeyName(workspaceId))
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+func appendServer(servers []*hcloud.Server, server *hcloud.Server) []*hcloud.Server {
+	if server == nil {
+		return servers
+	}
+	for _, existing := range servers {
+		if existing.ID == server.ID {
+			return servers
+		}
+	}
+	return append(servers, server)
+}
+
+func appendVolume(volumes []*hcloud.Volume, volume *hcloud.Volume) []*hcloud.Volume {
+	if volume == nil {
+		return volumes
+	}
+	for _, existing := range volumes {
+		if existing.ID == volume.ID {
+			return volumes
+		}
+	}
+	return append(volumes, volume)
+}
+
+// isWorkspaceVolume returns whether the volume is one of the volumes created for the workspace.
+func isWorkspaceVolume(workspaceVolumes []*hcloud.Volume, volume *hcloud.Volume) bool {
+	for _, workspaceVolume := range workspaceVolumes {
+		if workspaceVolume.ID == volume.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteServer deletes the server and waits until it is gone.
+func deleteServer(client *hcloud.Client, server *hcloud.Server) error {
+	return retryTeardown(fmt.Sprintf("server %s", server.Name), func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), teardownActionTimeout)
+		defer cancel()
+
+		result, _, err := client.Server.DeleteWithResult(ctx, server)
+		if err != nil {
+			return err
+		}
+		logger.Debug("deleting server", "server_id", server.ID, "hetzner_action_id", result.Action.ID)
+
+		return waitForAction(ctx, client, result.Action)
+	})
+}
+
+// deleteVolume detaches the volume if it is still attached, waits for the detach and deletes the volume.
+func deleteVolume(client *hcloud.Client, volume *hcloud.Volume) error {
+	return retryTeardown(fmt.Sprintf("volume %s", volume.Name), func() error {
+		err := detachVolume(client, volume)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.Volume.Delete(context.Background(), volume)
+		if err != nil {
+			return err
+		}
+		logger.Debug("deleted volume", "volume_id", volume.ID)
+
+		return nil
+	})
+}
+
+// detachVolume detaches the volume from its server, if any, and waits for the detach.
+func detachVolume(client *hcloud.Client, volume *hcloud.Volume) error {
+	ctx, cancel := context.WithTimeout(context.Background(), teardownActionTimeout)
+	defer cancel()
+
+	current, _, err := client.Volume.GetByID(ctx, volume.ID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "volume not found"}
+	}
+	if current.Server == nil {
+		return nil
+	}
+
+	action, _, err := client.Volume.Detach(ctx, current)
+	if err != nil {
+		return err
+	}
+	logger.Debug("detaching volume", "volume_id", volume.ID, "server_id", current.Server.ID, "hetzner_action_id", action.ID)
+
+	return waitForAction(ctx, client, action)
+}
+
+// deleteSSHKey deletes the SSH key.
+func deleteSSHKey(client *hcloud.Client, sshKey *hcloud.SSHKey) error {
+	return retryTeardown(fmt.Sprintf("SSH key %s", sshKey.Name), func() error {
+		_, err := client.SSHKey.Delete(context.Background(), sshKey)
+		return err
+	})
+}
+
+// retryTeardown runs a teardown step until it succeeds, fails permanently or runs out of attempts. A resource
+// that no longer exists counts as deleted.
+func retryTeardown(resource string, step func() error) error {
+	var err error
+	for attempt := 1; attempt <= teardownAttempts; attempt++ {
+		err = step()
+		if err == nil || hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+			return nil
+		}
+		if !IsRetryableError(err) {
+			break
+		}
+
+		logger.Debug("retrying teardown step", "resource", resource, "attempt", attempt, "error", err)
+		if attempt < teardownAttempts {
+			time.Sleep(teardownRetryDelay * time.Duration(attempt))
+		}
+	}
+
+	return fmt.Errorf("failed to delete %s: %w", resource, err)
+}
+
+// IsRetryableError returns whether a request may succeed when it is tried again. API errors other than
+// temporary ones, e.g. unauthorized, forbidden or protected, are permanent. Other errors, such as network
+// errors and failed actions, are retried.
+func IsRetryableError(err error) bool {
+	var apiErr hcloud.Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	switch apiErr.Code {
+	case hcloud.ErrorCodeLocked, hcloud.ErrorCodeConflict, hcloud.ErrorCodeRateLimitExceeded,
+		hcloud.ErrorCodeServiceError, hcloud.ErrorCodeMaintenance, hcloud.ErrorCodeUnknownError:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,261 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// fakeApi serves the Hetzner API for the teardown tests from canned responses keyed by "METHOD /path" and
+// records every request. Actions always succeed and any other resource is not found.
+type fakeApi struct {
+	responses map[string]fakeResponse
+	requests  []string
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func newFakeApi(t *testing.T, responses map[string]fakeResponse) *fakeApi {
+	api := &fakeApi{responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := r.Method + " " + r.URL.Path
+		api.requests = append(api.requests, request)
+
+		response, ok := api.responses[request]
+		if !ok || response.body != "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		switch {
+		case ok:
+			w.WriteHeader(response.status)
+			fmt.Fprint(w, response.body)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/actions/"):
+			fmt.Fprintf(w, `{"action": {"id": 1, "status": "success"}}`)
+		case strings.HasSuffix(r.URL.Path, "/actions/detach") || strings.HasSuffix(r.URL.Path, "/actions/change_protection"):
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"action": {"id": 1, "status": "running"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": "not_found", "message": "not found"}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(setApiEndpoint(server.URL))
+
+	retryDelay := teardownRetryDelay
+	teardownRetryDelay = 0
+	t.Cleanup(func() { teardownRetryDelay = retryDelay })
+
+	return api
+}
+
+// index returns the position of the first request, or -1 if it was not made.
+func (a *fakeApi) index(request string) int {
+	for i, made := range a.requests {
+		if made == request {
+			return i
+		}
+	}
+	return -1
+}
+
+const (
+	fakeServer     = `{"id": 1, "name": "daytona-ws", "status": "running", "volumes": [7], "protection": {"delete": %[1]t, "rebuild": %[1]t}}`
+	fakeVolume     = `{"id": 7, "name": "daytona-ws", "server": %[2]s, "location": {"name": "fsn1"}, "protection": {"delete": %[1]t}}`
+	fakeSshKey     = `{"id": 3, "name": "daytona-ws-diagnostics"}`
+	fakeNoServers  = `{"servers": []}`
+	deletedContent = ""
+)
+
+// workspaceResponses returns the responses for a workspace with a server, a volume and a diagnostics SSH key.
+func workspaceResponses(protected bool) map[string]fakeResponse {
+	return map[string]fakeResponse{
+		"GET /servers":       {http.StatusOK, fmt.Sprintf(`{"servers": [`+fakeServer+`]}`, protected)},
+		"GET /volumes":       {http.StatusOK, fmt.Sprintf(`{"volumes": [`+fakeVolume+`]}`, protected, "1")},
+		"GET /volumes/7":     {http.StatusOK, fmt.Sprintf(`{"volume": `+fakeVolume+`}`, protected, "null")},
+		"GET /ssh_keys":      {http.StatusOK, `{"ssh_keys": [` + fakeSshKey + `]}`},
+		"DELETE /servers/1":  {http.StatusOK, `{"action": {"id": 1, "status": "running"}}`},
+		"DELETE /volumes/7":  {http.StatusNoContent, deletedContent},
+		"DELETE /ssh_keys/3": {http.StatusNoContent, deletedContent},
+	}
+}
+
+func TestDeleteWorkspaceWithoutServer(t *testing.T) {
+	responses := workspaceResponses(false)
+	responses["GET /servers"] = fakeResponse{http.StatusOK, fakeNoServers}
+	api := newFakeApi(t, responses)
+
+	err := DeleteWorkspace(&workspace.Workspace{Id: "ws"}, &types.TargetOptions{APIToken: "token"}, false, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, request := range []string{"DELETE /volumes/7", "DELETE /ssh_keys/3"} {
+		if api.index(request) == -1 {
+			t.Errorf("expected %s, got %v", request, api.requests)
+		}
+	}
+}
+
+func TestDeleteWorkspaceNotFoundIsDeleted(t *testing.T) {
+	responses := workspaceResponses(false)
+	notFound := fakeResponse{http.StatusNotFound, `{"error": {"code": "not_found", "message": "not found"}}`}
+	responses["DELETE /servers/1"] = notFound
+	responses["DELETE /volumes/7"] = notFound
+	responses["DELETE /ssh_keys/3"] = notFound
+	newFakeApi(t, responses)
+
+	err := DeleteWorkspace(&workspace.Workspace{Id: "ws"}, &types.TargetOptions{APIToken: "token"}, false, &bytes.Buffer{})
+	if err != nil {
+		t.Errorf("expected resources that are already gone to count as deleted, got %s", err)
+	}
+}
+
+func TestDeleteWorkspaceJoinsErrors(t *testing.T) {
+	responses := workspaceResponses(false)
+	forbidden := fakeResponse{http.StatusForbidden, `{"error": {"code": "forbidden", "message": "forbidden"}}`}
+	responses["DELETE /volumes/7"] = forbidden
+	responses["DELETE /ssh_keys/3"] = forbidden
+	api := newFakeApi(t, responses)
+
+	err := DeleteWorkspace(&workspace.Workspace{Id: "ws"}, &types.TargetOptions{APIToken: "token"}, false, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	for _, want := range []string{"failed to delete volume daytona-ws", "failed to delete SSH key daytona-ws-diagnostics"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to contain %q, got %s", want, err)
+		}
+	}
+	if api.index("DELETE /ssh_keys/3") == -1 {
+		t.Errorf("expected the SSH key to be deleted after the volume failed, got %v", api.requests)
+	}
+}
+
+func TestRetryTeardown(t *testing.T) {
+	retryDelay := teardownRetryDelay
+	teardownRetryDelay = 0
+	defer func() { teardownRetryDelay = retryDelay }()
+
+	locked := hcloud.Error{Code: hcloud.ErrorCodeLocked, Message: "server is locked"}
+	forbidden := hcloud.Error{Code: hcloud.ErrorCodeForbidden, Message: "forbidden"}
+	notFound := hcloud.Error{Code: hcloud.ErrorCodeNotFound, Message: "not found"}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "success",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "not found is success",
+			errs:         []error{notFound},
+			wantAttempts: 1,
+		},
+		{
+			name:         "locked is retried",
+			errs:         []error{locked, locked, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "network errors are retried",
+			errs:         []error{errors.New("connection reset"), nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "forbidden is not retried",
+			errs:         []error{forbidden},
+			wantAttempts: 1,
+			wantErr:      forbidden,
+		},
+		{
+			name:         "attempts run out",
+			errs:         []error{locked, locked, locked, locked, locked},
+			wantAttempts: teardownAttempts,
+			wantErr:      locked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryTeardown("server daytona-123", func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWaitForActionTimeout(t *testing.T) {
+	pollInterval := actionPollInterval
+	actionPollInterval = time.Millisecond
+	defer func() { actionPollInterval = pollInterval }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"action": {"id": 1, "command": "delete_server", "status": "running"}}`)
+	}))
+	defer server.Close()
+
+	client := hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token"))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := waitForAction(ctx, client, &hcloud.Action{ID: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+	if !IsRetryableError(err) {
+		t.Errorf("expected a timed out action to be retried")
+	}
+}
+
+func TestAppendVolume(t *testing.T) {
+	volumes := []*hcloud.Volume{{ID: 1}, {ID: 2}}
+
+	got := appendVolume(volumes, &hcloud.Volume{ID: 2})
+	if len(got) != 2 {
+		t.Errorf("expected a volume found by label and name to be listed once, got %d volumes", len(got))
+	}
+
+	got = appendVolume(volumes, &hcloud.Volume{ID: 3})
+	if len(got) != 3 {
+		t.Errorf("expected a volume only found by name to be added, got %d volumes", len(got))
+	}
+
+	got = appendVolume(volumes, nil)
+	if len(got) != 2 {
+		t.Errorf("expected no volume to be added, got %d volumes", len(got))
+	}
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// volumeMount is an existing volume that is mounted on the workspace server.
+type volumeMount struct {
+	volume    *hcloud.Volume
+	mountPath string
+}
+
+// getVolumeMounts returns the existing volumes of the Attach Volumes option. Each volume must be in the
+// location of the server and must not be attached to another server.
+func getVolumeMounts(client *hcloud.Client, opts *types.TargetOptions, location *hcloud.Location) ([]volumeMount, error) {
+	attachments, err := opts.VolumeAttachments()
+	if err != nil {
+		return nil, err
+	}
+
+	var mounts []volumeMount
+	for _, attachment := range attachments {
+		volume, _, err := client.Volume.Get(context.Background(), attachment.Volume)
+		if err != nil {
+			return nil, err
+		}
+		if volume == nil {
+			return nil, fmt.Errorf("volume %s not found", attachment.Volume)
+		}
+
+		if volume.Location == nil || volume.Location.Name != location.Name {
+			volumeLocation := "an unknown location"
+			if volume.Location != nil {
+				volumeLocation = volume.Location.Name
+			}
+			return nil, fmt.Errorf("volume %s is in %s, but the server is created in %s", volume.Name, volumeLocation, location.Name)
+		}
+		if volume.Server != nil {
+			return nil, fmt.Errorf("volume %s is attached to server %d, detach it first", volume.Name, volume.Server.ID)
+		}
+
+		mounts = append(mounts, volumeMount{volume: volume, mountPath: attachment.MountPath})
+	}
+
+	return mounts, nil
+}
+
+// volumeDeviceWaitAttempts is how often the boot script checks for a volume device, every 2 seconds. The
+// volumes of the Attach Volumes option are only attached once the server is created, while it boots.
+const volumeDeviceWaitAttempts = 150
+
+// attachVolumes attaches the volumes of the Attach Volumes option to the server. Automount is disabled, so
+// that Hetzner does not mount them at /mnt/HC_Volume_<id> in addition to the mount path of the boot script.
+func attachVolumes(client *hcloud.Client, server *hcloud.Server, mounts []volumeMount) error {
+	for _, mount := range mounts {
+		action, _, err := client.Volume.AttachWithOpts(context.Background(), mount.volume, hcloud.VolumeAttachOpts{
+			Server:    server,
+			Automount: hcloud.Ptr(false),
+		})
+		if err == nil {
+			err = waitForAction(context.Background(), client, action)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to attach volume %s: %w", mount.volume.Name, err)
+		}
+		logger.Debug("attached volume", "server_id", server.ID, "volume_id", mount.volume.ID, "hetzner_action_id", action.ID)
+	}
+	return nil
+}
+
+// volumeMountScript returns the boot script lines that wait for each volume device and mount it at its mount
+// path through /etc/fstab, so that the mounts survive reboots. It runs after the daytona user is created, and
+// the mount point and the root of the mounted volume are owned by that user.
+func volumeMountScript(mounts []volumeMount) string {
+	var script strings.Builder
+	for _, mount := range mounts {
+		device := mount.volume.LinuxDevice
+		if device == "" {
+			device = fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", mount.volume.ID)
+		}
+
+		script.WriteString(fmt.Sprintf(`for i in $(seq 1 %[3]d); do [ -e %[1]s ] && break; sleep 2; done
+mkdir -p %[2]s
+chown daytona:daytona %[2]s
+grep -q " %[2]s " /etc/fstab || echo "%[1]s %[2]s auto discard,nofail,defaults 0 0" >> /etc/fstab
+mountpoint -q %[2]s || mount %[2]s
+chown daytona:daytona %[2]s
+
+`, device, mount.mountPath, volumeDeviceWaitAttempts))
+	}
+	return script.String()
+}
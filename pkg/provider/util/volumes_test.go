@@ -0,0 +1,71 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func TestVolumeMountScript(t *testing.T) {
+	script := volumeMountScript([]volumeMount{
+		{volume: &hcloud.Volume{ID: 1, LinuxDevice: "/dev/disk/by-id/scsi-0HC_Volume_1"}, mountPath: "/data"},
+		{volume: &hcloud.Volume{ID: 2}, mountPath: "/mnt/cache"},
+	})
+
+	for _, want := range []string{
+		`echo "/dev/disk/by-id/scsi-0HC_Volume_1 /data auto discard,nofail,defaults 0 0" >> /etc/fstab`,
+		"mountpoint -q /data || mount /data",
+		`[ -e /dev/disk/by-id/scsi-0HC_Volume_2 ]`,
+		"mkdir -p /mnt/cache",
+		"chown daytona:daytona /mnt/cache",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected the mount script to contain %q, got:\n%s", want, script)
+		}
+	}
+
+	if volumeMountScript(nil) != "" {
+		t.Errorf("expected no mount script without volumes")
+	}
+}
+
+func TestAttachVolumesDisablesAutomount(t *testing.T) {
+	attached := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost || r.URL.Path != "/volumes/7/actions/attach" {
+			// Polling of the attach action.
+			fmt.Fprint(w, `{"action": {"id": 1, "status": "success"}}`)
+			return
+		}
+
+		var body struct {
+			Server    int   `json:"server"`
+			Automount *bool `json:"automount"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Errorf("failed to decode the attach request: %s", err)
+		}
+		if body.Server != 42 || body.Automount == nil || *body.Automount {
+			t.Errorf("expected the volume to be attached to server 42 without automount, got %+v", body)
+		}
+		attached++
+		fmt.Fprint(w, `{"action": {"id": 1, "status": "running"}}`)
+	}))
+	defer server.Close()
+
+	client := hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token"))
+	err := attachVolumes(client, &hcloud.Server{ID: 42}, []volumeMount{{volume: &hcloud.Volume{ID: 7, Name: "datasets"}, mountPath: "/data"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if attached != 1 {
+		t.Errorf("expected the volume to be attached once, got %d attach requests", attached)
+	}
+}
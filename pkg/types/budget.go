@@ -0,0 +1,44 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetStatus is the monthly spend of a budget scope before and after creating a workspace.
+type BudgetStatus struct {
+	Current   float64
+	New       float64
+	Projected float64
+	Cap       float64
+	// Warning is set when the projected spend reaches the warning threshold of the cap.
+	Warning bool
+}
+
+// CheckBudget evaluates the projected monthly spend against the budget target options.
+// It returns ErrBudgetExceeded when the cap would be exceeded by the new workspace.
+func CheckBudget(currentMonthly, newMonthly float64, opts *TargetOptions) (*BudgetStatus, error) {
+	status := &BudgetStatus{
+		Current:   currentMonthly,
+		New:       newMonthly,
+		Projected: currentMonthly + newMonthly,
+		Cap:       opts.MaxMonthlySpend,
+	}
+
+	if opts.MaxMonthlySpend <= 0 {
+		return status, nil
+	}
+
+	if status.Projected > status.Cap {
+		return status, fmt.Errorf("%w: projected monthly spend of %.2f (current %.2f + new workspace %.2f) exceeds the cap of %.2f",
+			ErrBudgetExceeded, status.Projected, status.Current, status.New, status.Cap)
+	}
+
+	if opts.BudgetWarningThreshold > 0 && status.Projected >= status.Cap*opts.BudgetWarningThreshold/100 {
+		status.Warning = true
+	}
+
+	return status, nil
+}
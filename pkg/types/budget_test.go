@@ -0,0 +1,72 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckBudget(t *testing.T) {
+	tests := []struct {
+		name           string
+		currentMonthly float64
+		newMonthly     float64
+		opts           *TargetOptions
+		wantWarning    bool
+		wantErr        error
+	}{
+		{
+			name:           "Budget disabled",
+			currentMonthly: 1000,
+			newMonthly:     10,
+			opts:           &TargetOptions{},
+		},
+		{
+			name:           "Below warning threshold",
+			currentMonthly: 10,
+			newMonthly:     5,
+			opts:           &TargetOptions{MaxMonthlySpend: 100, BudgetWarningThreshold: 80},
+		},
+		{
+			name:           "Above warning threshold",
+			currentMonthly: 75,
+			newMonthly:     10,
+			opts:           &TargetOptions{MaxMonthlySpend: 100, BudgetWarningThreshold: 80},
+			wantWarning:    true,
+		},
+		{
+			name:           "Warning threshold disabled",
+			currentMonthly: 75,
+			newMonthly:     10,
+			opts:           &TargetOptions{MaxMonthlySpend: 100},
+		},
+		{
+			name:           "Exactly at the cap",
+			currentMonthly: 90,
+			newMonthly:     10,
+			opts:           &TargetOptions{MaxMonthlySpend: 100, BudgetWarningThreshold: 80},
+			wantWarning:    true,
+		},
+		{
+			name:           "Cap exceeded",
+			currentMonthly: 95,
+			newMonthly:     10,
+			opts:           &TargetOptions{MaxMonthlySpend: 100, BudgetWarningThreshold: 80},
+			wantErr:        ErrBudgetExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := CheckBudget(tt.currentMonthly, tt.newMonthly, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckBudget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status.Warning != tt.wantWarning {
+				t.Errorf("CheckBudget() warning = %v, want %v", status.Warning, tt.wantWarning)
+			}
+			if status.Projected != tt.currentMonthly+tt.newMonthly {
+				t.Errorf("CheckBudget() projected = %f, want %f", status.Projected, tt.currentMonthly+tt.newMonthly)
+			}
+		})
+	}
+}
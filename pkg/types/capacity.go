@@ -0,0 +1,60 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProjectUsage is the number of resources counted against the Hetzner project limits.
+type ProjectUsage struct {
+	Servers int
+	Cores   int
+	Volumes int
+}
+
+// LimitError is returned when creating a workspace would exceed a Hetzner project limit.
+type LimitError struct {
+	Limit     string
+	Used      int
+	Requested int
+	Max       int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("project %s limit exceeded: %d in use, %d requested, limit is %d", e.Limit, e.Used, e.Requested, e.Max)
+}
+
+// CheckProjectLimits checks that a new server with the given number of cores and new volumes fits in the
+// project limits configured in the target options. Limits that are not set are not checked.
+func CheckProjectLimits(usage ProjectUsage, serverCores, newVolumes int, opts *TargetOptions) error {
+	checks := []LimitError{
+		{Limit: "server", Used: usage.Servers, Requested: 1, Max: opts.ServerLimit},
+		{Limit: "core", Used: usage.Cores, Requested: serverCores, Max: opts.CoreLimit},
+		{Limit: "volume", Used: usage.Volumes, Requested: newVolumes, Max: opts.VolumeLimit},
+	}
+
+	for _, check := range checks {
+		if check.Max > 0 && check.Used+check.Requested > check.Max {
+			return &check
+		}
+	}
+
+	return nil
+}
+
+// CandidateLocations returns the preferred location followed by the fallback locations, without duplicates.
+func (o *TargetOptions) CandidateLocations() []string {
+	locations := []string{}
+	seen := map[string]bool{}
+
+	for _, location := range append([]string{o.Location}, strings.Split(o.FallbackLocations, ",")...) {
+		location = strings.TrimSpace(location)
+		if location == "" || seen[location] {
+			continue
+		}
+		seen[location] = true
+		locations = append(locations, location)
+	}
+
+	return locations
+}
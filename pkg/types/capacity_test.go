@@ -0,0 +1,116 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckProjectLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		usage     ProjectUsage
+		cores     int
+		volumes   int
+		opts      *TargetOptions
+		wantLimit string
+	}{
+		{
+			name:    "No limits configured",
+			usage:   ProjectUsage{Servers: 100, Cores: 400, Volumes: 100},
+			cores:   2,
+			volumes: 1,
+			opts:    &TargetOptions{},
+		},
+		{
+			name:    "Within all limits",
+			usage:   ProjectUsage{Servers: 3, Cores: 6, Volumes: 3},
+			cores:   2,
+			volumes: 1,
+			opts:    &TargetOptions{ServerLimit: 5, CoreLimit: 10, VolumeLimit: 5},
+		},
+		{
+			name:      "Server limit reached",
+			usage:     ProjectUsage{Servers: 5, Cores: 6, Volumes: 3},
+			cores:     2,
+			volumes:   1,
+			opts:      &TargetOptions{ServerLimit: 5, CoreLimit: 10, VolumeLimit: 5},
+			wantLimit: "server",
+		},
+		{
+			name:      "Core limit exceeded",
+			usage:     ProjectUsage{Servers: 3, Cores: 9, Volumes: 3},
+			cores:     2,
+			volumes:   1,
+			opts:      &TargetOptions{ServerLimit: 5, CoreLimit: 10, VolumeLimit: 5},
+			wantLimit: "core",
+		},
+		{
+			name:      "Volume limit reached",
+			usage:     ProjectUsage{Servers: 3, Cores: 6, Volumes: 5},
+			cores:     2,
+			volumes:   1,
+			opts:      &TargetOptions{ServerLimit: 5, CoreLimit: 10, VolumeLimit: 5},
+			wantLimit: "volume",
+		},
+		{
+			name:  "Volume limit reached but the volume is reused",
+			usage: ProjectUsage{Servers: 3, Cores: 6, Volumes: 5},
+			cores: 2,
+			opts:  &TargetOptions{ServerLimit: 5, CoreLimit: 10, VolumeLimit: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckProjectLimits(tt.usage, tt.cores, tt.volumes, tt.opts)
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Errorf("CheckProjectLimits() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("CheckProjectLimits() error = %v, want LimitError", err)
+			}
+			if limitErr.Limit != tt.wantLimit {
+				t.Errorf("CheckProjectLimits() limit = %s, want %s", limitErr.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestCandidateLocations(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *TargetOptions
+		want []string
+	}{
+		{
+			name: "Preferred location only",
+			opts: &TargetOptions{Location: "fsn1"},
+			want: []string{"fsn1"},
+		},
+		{
+			name: "Fallback locations in order",
+			opts: &TargetOptions{Location: "fsn1", FallbackLocations: "nbg1, hel1"},
+			want: []string{"fsn1", "nbg1", "hel1"},
+		},
+		{
+			name: "Duplicates and empty entries are skipped",
+			opts: &TargetOptions{Location: "fsn1", FallbackLocations: "nbg1,,fsn1,nbg1"},
+			want: []string{"fsn1", "nbg1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.CandidateLocations()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CandidateLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
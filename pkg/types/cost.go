@@ -0,0 +1,246 @@
+package types
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// hoursPerMonth is used to derive hourly prices for resources that Hetzner only prices per month.
+const hoursPerMonth = 730
+
+type Cost struct {
+	Hourly  float64
+	Monthly float64
+}
+
+func (c Cost) add(other Cost) Cost {
+	return Cost{
+		Hourly:  c.Hourly + other.Hourly,
+		Monthly: c.Monthly + other.Monthly,
+	}
+}
+
+// CostEstimate is the gross cost breakdown of a workspace in the pricing currency.
+type CostEstimate struct {
+	Currency   string
+	Server     Cost
+	Volumes    Cost
+	PrimaryIPs Cost
+	Backups    Cost
+	Total      Cost
+}
+
+// CostEstimateOpts describes the resources of a workspace that should be priced.
+type CostEstimateOpts struct {
+	Location       string
+	ServerType     string
+	VolumeSizes    []int
+	PrimaryIPTypes []hcloud.PrimaryIPType
+	Backups        bool
+}
+
+// EstimateCost calculates the hourly and monthly cost of a workspace from the Hetzner pricing.
+func EstimateCost(pricing *hcloud.Pricing, opts CostEstimateOpts) (*CostEstimate, error) {
+	serverPrice, err := findServerTypePrice(pricing, opts.ServerType, opts.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &CostEstimate{Currency: serverPrice.Hourly.Currency}
+
+	estimate.Server, err = toCost(serverPrice.Hourly.Gross, serverPrice.Monthly.Gross)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.VolumeSizes) > 0 {
+		estimate.Volumes, err = EstimateVolumeCost(pricing, opts.VolumeSizes...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ipType := range opts.PrimaryIPTypes {
+		ipCost, err := EstimatePrimaryIPCost(pricing, ipType, opts.Location)
+		if err != nil {
+			return nil, err
+		}
+		estimate.PrimaryIPs = estimate.PrimaryIPs.add(ipCost)
+	}
+
+	if opts.Backups {
+		percentage, err := strconv.ParseFloat(pricing.ServerBackup.Percentage, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup price percentage %q: %w", pricing.ServerBackup.Percentage, err)
+		}
+		estimate.Backups = Cost{
+			Hourly:  estimate.Server.Hourly * percentage / 100,
+			Monthly: estimate.Server.Monthly * percentage / 100,
+		}
+	}
+
+	estimate.Total = estimate.Server.add(estimate.Volumes).add(estimate.PrimaryIPs).add(estimate.Backups)
+
+	return estimate, nil
+}
+
+// EstimateServerCost calculates the cost of an existing server and the given volumes.
+func EstimateServerCost(pricing *hcloud.Pricing, server *hcloud.Server, volumes []*hcloud.Volume) (*CostEstimate, error) {
+	if server.ServerType == nil || server.Datacenter == nil || server.Datacenter.Location == nil {
+		return nil, fmt.Errorf("server %s is missing its type or location", server.Name)
+	}
+
+	opts := CostEstimateOpts{
+		Location:   server.Datacenter.Location.Name,
+		ServerType: server.ServerType.Name,
+		Backups:    server.BackupWindow != "",
+	}
+
+	for _, volume := range volumes {
+		opts.VolumeSizes = append(opts.VolumeSizes, volume.Size)
+	}
+
+	if server.PublicNet.IPv4.ID != 0 {
+		opts.PrimaryIPTypes = append(opts.PrimaryIPTypes, hcloud.PrimaryIPTypeIPv4)
+	}
+	if server.PublicNet.IPv6.ID != 0 {
+		opts.PrimaryIPTypes = append(opts.PrimaryIPTypes, hcloud.PrimaryIPTypeIPv6)
+	}
+
+	return EstimateCost(pricing, opts)
+}
+
+func findServerTypePrice(pricing *hcloud.Pricing, serverType, location string) (*hcloud.ServerTypeLocationPricing, error) {
+	for _, serverTypePricing := range pricing.ServerTypes {
+		if serverTypePricing.ServerType == nil || serverTypePricing.ServerType.Name != serverType {
+			continue
+		}
+		for _, locationPricing := range serverTypePricing.Pricings {
+			if locationPricing.Location != nil && locationPricing.Location.Name == location {
+				return &locationPricing, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no price found for server type %s in location %s", serverType, location)
+}
+
+// EstimateVolumeCost calculates the cost of volumes with the given sizes in GB.
+func EstimateVolumeCost(pricing *hcloud.Pricing, sizes ...int) (Cost, error) {
+	perGB, err := strconv.ParseFloat(pricing.Volume.PerGBMonthly.Gross, 64)
+	if err != nil {
+		return Cost{}, fmt.Errorf("invalid volume price %q: %w", pricing.Volume.PerGBMonthly.Gross, err)
+	}
+
+	var cost Cost
+	for _, size := range sizes {
+		monthly := perGB * float64(size)
+		cost = cost.add(Cost{Hourly: monthly / hoursPerMonth, Monthly: monthly})
+	}
+
+	return cost, nil
+}
+
+// EstimatePrimaryIPCost calculates the cost of a primary IP of the given type in a location.
+func EstimatePrimaryIPCost(pricing *hcloud.Pricing, ipType hcloud.PrimaryIPType, location string) (Cost, error) {
+	for _, primaryIPPricing := range pricing.PrimaryIPs {
+		if primaryIPPricing.Type != string(ipType) {
+			continue
+		}
+		for _, locationPricing := range primaryIPPricing.Pricings {
+			if locationPricing.Location == location {
+				return toCost(locationPricing.Hourly.Gross, locationPricing.Monthly.Gross)
+			}
+		}
+	}
+
+	// Hetzner does not charge for every primary IP type, e.g. IPv6.
+	return Cost{}, nil
+}
+
+func toCost(hourly, monthly string) (Cost, error) {
+	hourlyPrice, err := strconv.ParseFloat(hourly, 64)
+	if err != nil {
+		return Cost{}, fmt.Errorf("invalid hourly price %q: %w", hourly, err)
+	}
+
+	monthlyPrice, err := strconv.ParseFloat(monthly, 64)
+	if err != nil {
+		return Cost{}, fmt.Errorf("invalid monthly price %q: %w", monthly, err)
+	}
+
+	return Cost{Hourly: hourlyPrice, Monthly: monthlyPrice}, nil
+}
+
+// CostReportEntry is the cost of a single resource created by the provider.
+type CostReportEntry struct {
+	ResourceType string
+	ID           int
+	Name         string
+	WorkspaceId  string
+	Location     string
+	Cost         Cost
+}
+
+// CostReport aggregates the cost of all resources created by the provider in a Hetzner project.
+type CostReport struct {
+	Currency string
+	Entries  []CostReportEntry
+	Total    Cost
+}
+
+// AddEntry adds a resource to the report and updates the total.
+func (r *CostReport) AddEntry(entry CostReportEntry) {
+	r.Entries = append(r.Entries, entry)
+	r.Total = r.Total.add(entry.Cost)
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *CostReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes one row per resource followed by a total row.
+func (r *CostReport) WriteCSV(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+
+	err := csvWriter.Write([]string{"resource_type", "id", "name", "workspace_id", "location", "hourly", "monthly", "currency"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range r.Entries {
+		err = csvWriter.Write([]string{
+			entry.ResourceType,
+			strconv.Itoa(entry.ID),
+			entry.Name,
+			entry.WorkspaceId,
+			entry.Location,
+			formatPrice(entry.Cost.Hourly),
+			formatPrice(entry.Cost.Monthly),
+			r.Currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = csvWriter.Write([]string{"total", "", "", "", "", formatPrice(r.Total.Hourly), formatPrice(r.Total.Monthly), r.Currency})
+	if err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', 4, 64)
+}
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func costAlmostEqual(a, b Cost) bool {
+	return math.Abs(a.Hourly-b.Hourly) < 1e-9 && math.Abs(a.Monthly-b.Monthly) < 1e-9
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    CostEstimateOpts
+		want    *CostEstimate
+		wantErr bool
+	}{
+		{
+			name: "Server only",
+			opts: CostEstimateOpts{Location: "fsn1", ServerType: "cpx11"},
+			want: &CostEstimate{
+				Currency: "EUR",
+				Server:   Cost{Hourly: 0.008, Monthly: 4.85},
+				Total:    Cost{Hourly: 0.008, Monthly: 4.85},
+			},
+		},
+		{
+			name: "Server with volume, primary IPs and backups",
+			opts: CostEstimateOpts{
+				Location:       "fsn1",
+				ServerType:     "cpx11",
+				VolumeSizes:    []int{20, 10},
+				PrimaryIPTypes: []hcloud.PrimaryIPType{hcloud.PrimaryIPTypeIPv4, hcloud.PrimaryIPTypeIPv6},
+				Backups:        true,
+			},
+			want: &CostEstimate{
+				Currency:   "EUR",
+				Server:     Cost{Hourly: 0.008, Monthly: 4.85},
+				Volumes:    Cost{Hourly: 1.572 / hoursPerMonth, Monthly: 1.572},
+				PrimaryIPs: Cost{Hourly: 0.0008, Monthly: 0.5},
+				Backups:    Cost{Hourly: 0.0016, Monthly: 0.97},
+				Total:      Cost{Hourly: 0.008 + 1.572/hoursPerMonth + 0.0008 + 0.0016, Monthly: 4.85 + 1.572 + 0.5 + 0.97},
+			},
+		},
+		{
+			name:    "Unknown server type",
+			opts:    CostEstimateOpts{Location: "fsn1", ServerType: "cpx99"},
+			wantErr: true,
+		},
+		{
+			name:    "Server type not priced in location",
+			opts:    CostEstimateOpts{Location: "ash", ServerType: "cpx11"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateCost(fixturePricing(), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EstimateCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Currency != tt.want.Currency {
+				t.Errorf("EstimateCost() currency = %s, want %s", got.Currency, tt.want.Currency)
+			}
+			if !costAlmostEqual(got.Server, tt.want.Server) {
+				t.Errorf("EstimateCost() server = %+v, want %+v", got.Server, tt.want.Server)
+			}
+			if !costAlmostEqual(got.Volumes, tt.want.Volumes) {
+				t.Errorf("EstimateCost() volumes = %+v, want %+v", got.Volumes, tt.want.Volumes)
+			}
+			if !costAlmostEqual(got.PrimaryIPs, tt.want.PrimaryIPs) {
+				t.Errorf("EstimateCost() primary IPs = %+v, want %+v", got.PrimaryIPs, tt.want.PrimaryIPs)
+			}
+			if !costAlmostEqual(got.Backups, tt.want.Backups) {
+				t.Errorf("EstimateCost() backups = %+v, want %+v", got.Backups, tt.want.Backups)
+			}
+			if !costAlmostEqual(got.Total, tt.want.Total) {
+				t.Errorf("EstimateCost() total = %+v, want %+v", got.Total, tt.want.Total)
+			}
+		})
+	}
+}
+
+func TestCostReport(t *testing.T) {
+	report := &CostReport{Currency: "EUR"}
+	report.AddEntry(CostReportEntry{
+		ResourceType: "server",
+		ID:           42,
+		Name:         "daytona-123",
+		WorkspaceId:  "123",
+		Location:     "fsn1",
+		Cost:         Cost{Hourly: 0.008, Monthly: 4.85},
+	})
+	report.AddEntry(CostReportEntry{
+		ResourceType: "volume",
+		ID:           7,
+		Name:         "daytona-123",
+		WorkspaceId:  "123",
+		Location:     "fsn1",
+		Cost:         Cost{Hourly: 0.0014, Monthly: 1.048},
+	})
+
+	if !costAlmostEqual(report.Total, Cost{Hourly: 0.0094, Monthly: 5.898}) {
+		t.Errorf("CostReport total = %+v", report.Total)
+	}
+
+	var csvOutput bytes.Buffer
+	err := report.WriteCSV(&csvOutput)
+	if err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	wantCsv := strings.Join([]string{
+		"resource_type,id,name,workspace_id,location,hourly,monthly,currency",
+		"server,42,daytona-123,123,fsn1,0.0080,4.8500,EUR",
+		"volume,7,daytona-123,123,fsn1,0.0014,1.0480,EUR",
+		"total,,,,,0.0094,5.8980,EUR",
+		"",
+	}, "\n")
+	if csvOutput.String() != wantCsv {
+		t.Errorf("WriteCSV() = %q, want %q", csvOutput.String(), wantCsv)
+	}
+
+	var jsonOutput bytes.Buffer
+	err = report.WriteJSON(&jsonOutput)
+	if err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if !strings.Contains(jsonOutput.String(), `"ResourceType": "volume"`) {
+		t.Errorf("WriteJSON() output is missing the volume entry: %s", jsonOutput.String())
+	}
+}
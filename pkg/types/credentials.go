@@ -0,0 +1,266 @@
+package types
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialCommandTimeout bounds external commands such as password manager CLIs, which may wait for an unlock.
+const credentialCommandTimeout = 30 * time.Second
+
+// CredentialSource is a place the Hetzner API token can be read from.
+type CredentialSource interface {
+	// Resolve returns the token, or an empty string if the source is not configured.
+	Resolve() (string, error)
+	// Name describes the source in errors and logs. It never contains the token.
+	Name() string
+}
+
+// invalidatingCredentialSource is a source that caches its token and can drop it, e.g. after a 401.
+type invalidatingCredentialSource interface {
+	CredentialSource
+	Invalidate()
+}
+
+// InlineCredentialSource is a token set in the target options.
+type InlineCredentialSource struct {
+	Token string
+}
+
+func (s InlineCredentialSource) Resolve() (string, error) {
+	return s.Token, nil
+}
+
+func (s InlineCredentialSource) Name() string {
+	return "API Token option"
+}
+
+// EnvCredentialSource reads the token from an env var.
+type EnvCredentialSource struct {
+	EnvVar string
+}
+
+func (s EnvCredentialSource) Resolve() (string, error) {
+	return os.Getenv(s.EnvVar), nil
+}
+
+func (s EnvCredentialSource) Name() string {
+	return s.EnvVar + " env var"
+}
+
+// HcloudContextCredentialSource reads the token of a context from the hcloud CLI config. An empty context
+// name selects the active context. A named context must exist.
+type HcloudContextCredentialSource struct {
+	Context string
+}
+
+func (s HcloudContextCredentialSource) Resolve() (string, error) {
+	configPath, err := HcloudConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	config, err := ReadHcloudConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+	if config == nil {
+		if s.Context != "" {
+			return "", fmt.Errorf("hcloud context %s not found, %s does not exist", s.Context, configPath)
+		}
+		return "", nil
+	}
+
+	return config.Token(s.Context)
+}
+
+func (s HcloudContextCredentialSource) Name() string {
+	if s.Context == "" {
+		return "active hcloud context"
+	}
+	return "hcloud context " + s.Context
+}
+
+// FileCredentialSource reads the token from a file, e.g. a mounted secret. The token is cached in memory.
+type FileCredentialSource struct {
+	Path string
+}
+
+func (s FileCredentialSource) Resolve() (string, error) {
+	if s.Path == "" {
+		return "", nil
+	}
+
+	return credentialCache.get(s.cacheKey(), func() (string, error) {
+		content, err := os.ReadFile(s.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the API token file: %w", err)
+		}
+
+		token := strings.TrimSpace(string(content))
+		if token == "" {
+			return "", fmt.Errorf("the API token file %s is empty", s.Path)
+		}
+		return token, nil
+	})
+}
+
+func (s FileCredentialSource) Name() string {
+	return "API token file " + s.Path
+}
+
+func (s FileCredentialSource) Invalidate() {
+	credentialCache.delete(s.cacheKey())
+}
+
+func (s FileCredentialSource) cacheKey() string {
+	return "file:" + s.Path
+}
+
+// CommandCredentialSource runs a shell command, such as `op read op://vault/hetzner/token`, and uses its
+// standard output as the token. The token is cached in memory so the command only runs once per process.
+type CommandCredentialSource struct {
+	Command string
+}
+
+func (s CommandCredentialSource) Resolve() (string, error) {
+	if s.Command == "" {
+		return "", nil
+	}
+
+	return credentialCache.get(s.cacheKey(), func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), credentialCommandTimeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", s.Command)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err := cmd.Run()
+		if err != nil {
+			return "", fmt.Errorf("the API token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		token := strings.TrimSpace(stdout.String())
+		if token == "" {
+			return "", errors.New("the API token command printed no token")
+		}
+		return token, nil
+	})
+}
+
+func (s CommandCredentialSource) Name() string {
+	return "API token command"
+}
+
+func (s CommandCredentialSource) Invalidate() {
+	credentialCache.delete(s.cacheKey())
+}
+
+func (s CommandCredentialSource) cacheKey() string {
+	return "command:" + s.Command
+}
+
+// CredentialResolver returns the token of the first configured source.
+type CredentialResolver struct {
+	sources []CredentialSource
+
+	mu     sync.Mutex
+	token  string
+	source CredentialSource
+}
+
+func NewCredentialResolver(sources ...CredentialSource) *CredentialResolver {
+	return &CredentialResolver{sources: sources}
+}
+
+// Token returns the resolved token, resolving it on first use.
+func (r *CredentialResolver) Token() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.token != "" {
+		return r.token, nil
+	}
+
+	for _, source := range r.sources {
+		token, err := source.Resolve()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", source.Name(), err)
+		}
+		if token != "" {
+			r.token = token
+			r.source = source
+			return token, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Refresh drops the cached token and resolves it again. It is used when the API rejects the token, e.g.
+// after it was rotated in the password manager.
+func (r *CredentialResolver) Refresh() (string, error) {
+	r.mu.Lock()
+	if source, ok := r.source.(invalidatingCredentialSource); ok {
+		source.Invalidate()
+	}
+	r.token = ""
+	r.source = nil
+	r.mu.Unlock()
+
+	return r.Token()
+}
+
+// tokenCache keeps resolved tokens in memory for the lifetime of the provider process. Each key has its own
+// lock, so a slow API Token Command only blocks the callers waiting for the same token.
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[string]*tokenCacheEntry
+}
+
+type tokenCacheEntry struct {
+	mu    sync.Mutex
+	token string
+}
+
+var credentialCache = &tokenCache{entries: map[string]*tokenCacheEntry{}}
+
+func (c *tokenCache) get(key string, resolve func() (string, error)) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &tokenCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.token != "" {
+		return entry.token, nil
+	}
+
+	token, err := resolve()
+	if err != nil {
+		return "", err
+	}
+	entry.token = token
+	return token, nil
+}
+
+func (c *tokenCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
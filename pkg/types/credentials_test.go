@@ -0,0 +1,210 @@
+package types
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeCredentialSource struct {
+	token       string
+	err         error
+	resolved    int
+	invalidated int
+}
+
+func (s *fakeCredentialSource) Resolve() (string, error) {
+	s.resolved++
+	return s.token, s.err
+}
+
+func (s *fakeCredentialSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeCredentialSource) Invalidate() {
+	s.invalidated++
+}
+
+func TestCredentialResolver(t *testing.T) {
+	empty := &fakeCredentialSource{}
+	first := &fakeCredentialSource{token: "first-token"}
+	second := &fakeCredentialSource{token: "second-token"}
+
+	resolver := NewCredentialResolver(empty, first, second)
+
+	for i := 0; i < 2; i++ {
+		token, err := resolver.Token()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if token != "first-token" {
+			t.Errorf("expected the first configured source, got %q", token)
+		}
+	}
+	if first.resolved != 1 || second.resolved != 0 {
+		t.Errorf("expected the token to be resolved once from the first source, got %d and %d", first.resolved, second.resolved)
+	}
+
+	first.token = "rotated-token"
+	token, err := resolver.Refresh()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if token != "rotated-token" || first.invalidated != 1 {
+		t.Errorf("expected refresh to invalidate and resolve again, got %q and %d invalidations", token, first.invalidated)
+	}
+}
+
+func TestCredentialResolverError(t *testing.T) {
+	failing := &fakeCredentialSource{err: errors.New("locked")}
+	resolver := NewCredentialResolver(failing, &fakeCredentialSource{token: "token"})
+
+	_, err := resolver.Token()
+	if err == nil {
+		t.Errorf("expected the error of the source instead of falling through")
+	}
+}
+
+func TestFileCredentialSource(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token file: %s", err)
+	}
+
+	source := FileCredentialSource{Path: tokenFile}
+	defer source.Invalidate()
+
+	token, err := source.Resolve()
+	if err != nil || token != "file-token" {
+		t.Fatalf("expected file-token, got %q, %v", token, err)
+	}
+
+	err = os.WriteFile(tokenFile, []byte("rotated-token"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token file: %s", err)
+	}
+
+	token, _ = source.Resolve()
+	if token != "file-token" {
+		t.Errorf("expected the cached token, got %q", token)
+	}
+
+	source.Invalidate()
+	token, _ = source.Resolve()
+	if token != "rotated-token" {
+		t.Errorf("expected the rotated token after invalidation, got %q", token)
+	}
+
+	_, err = FileCredentialSource{Path: filepath.Join(t.TempDir(), "missing")}.Resolve()
+	if err == nil {
+		t.Errorf("expected an error for a missing token file")
+	}
+}
+
+func TestCommandCredentialSource(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	source := CommandCredentialSource{Command: "echo run >> " + counter + " && echo ' command-token '"}
+	defer source.Invalidate()
+
+	for i := 0; i < 2; i++ {
+		token, err := source.Resolve()
+		if err != nil || token != "command-token" {
+			t.Fatalf("expected command-token, got %q, %v", token, err)
+		}
+	}
+
+	runs, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatalf("failed to read the run counter: %s", err)
+	}
+	if string(runs) != "run\n" {
+		t.Errorf("expected the command to run once, got %q", runs)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"failing command", "echo locked >&2; exit 1"},
+		{"empty output", "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CommandCredentialSource{Command: tt.command}.Resolve()
+			if err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseTargetOptionsCredentialSources(t *testing.T) {
+	clearTokenEnv(t)
+	t.Setenv("HETZNER_API_TOKEN", "env-token")
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("file-token"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token file: %s", err)
+	}
+	defer FileCredentialSource{Path: tokenFile}.Invalidate()
+	defer CommandCredentialSource{Command: "echo command-token"}.Invalidate()
+
+	tests := []struct {
+		name        string
+		optionsJson string
+		want        string
+	}{
+		{"inline token wins", `{"API Token": "inline-token", "API Token File": "` + tokenFile + `"}`, "inline-token"},
+		{"token file wins over command", `{"API Token File": "` + tokenFile + `", "API Token Command": "echo command-token"}`, "file-token"},
+		{"command wins over env", `{"API Token Command": "echo command-token"}`, "command-token"},
+		{"env", `{}`, "env-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseTargetOptions(tt.optionsJson)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if opts.APIToken != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, opts.APIToken)
+			}
+		})
+	}
+}
+
+func TestTokenCacheDoesNotBlockOtherKeys(t *testing.T) {
+	cache := &tokenCache{entries: map[string]*tokenCacheEntry{}}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go cache.get("command:slow", func() (string, error) {
+		close(started)
+		<-release
+		return "slow-token", nil
+	})
+	<-started
+	defer close(release)
+
+	done := make(chan string)
+	go func() {
+		token, _ := cache.get("file:other", func() (string, error) {
+			return "other-token", nil
+		})
+		done <- token
+	}()
+
+	select {
+	case token := <-done:
+		if token != "other-token" {
+			t.Errorf("expected other-token, got %q", token)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("resolving a token was blocked by a slow command for another token")
+	}
+}
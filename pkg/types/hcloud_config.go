@@ -0,0 +1,79 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// HcloudConfigEnvVar overrides the path of the hcloud CLI config, as in the hcloud CLI.
+	HcloudConfigEnvVar = "HCLOUD_CONFIG"
+	// HcloudTokenEnvVar holds a token for the hcloud CLI.
+	HcloudTokenEnvVar = "HCLOUD_TOKEN"
+	// HcloudContextEnvVar selects a context of the hcloud CLI config.
+	HcloudContextEnvVar = "HCLOUD_CONTEXT"
+)
+
+// HcloudConfig is the part of the hcloud CLI config that holds the API tokens.
+type HcloudConfig struct {
+	ActiveContext string          `toml:"active_context"`
+	Contexts      []HcloudContext `toml:"contexts"`
+}
+
+type HcloudContext struct {
+	Name  string `toml:"name"`
+	Token string `toml:"token"`
+}
+
+// HcloudConfigPath returns the path of the hcloud CLI config, ~/.config/hcloud/cli.toml by default.
+func HcloudConfigPath() (string, error) {
+	if path, ok := os.LookupEnv(HcloudConfigEnvVar); ok {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, ".config", "hcloud", "cli.toml"), nil
+}
+
+// ReadHcloudConfig reads the hcloud CLI config. It returns nil if the file does not exist.
+func ReadHcloudConfig(path string) (*HcloudConfig, error) {
+	var config HcloudConfig
+	_, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid hcloud config %s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Token returns the token of the named context, or of the active context if name is empty.
+func (c *HcloudConfig) Token(name string) (string, error) {
+	if name == "" {
+		name = c.ActiveContext
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	for _, context := range c.Contexts {
+		if context.Name == name {
+			if context.Token == "" {
+				return "", fmt.Errorf("hcloud context %s has no token", name)
+			}
+			return context.Token, nil
+		}
+	}
+
+	return "", fmt.Errorf("hcloud context %s not found", name)
+}
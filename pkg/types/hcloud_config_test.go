@@ -0,0 +1,133 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testHcloudConfig = `active_context = "personal"
+
+[[contexts]]
+  name = "personal"
+  token = "personal-token"
+
+[[contexts]]
+  name = "work"
+  token = "work-token"
+
+[[contexts]]
+  name = "empty"
+  token = ""
+`
+
+func TestParseTargetOptionsTokenPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		optionsJson string
+		envVars     map[string]string
+		noConfig    bool
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "explicit token wins over everything",
+			optionsJson: `{"API Token": "inline-token", "Context": "work"}`,
+			envVars:     map[string]string{"HETZNER_API_TOKEN": "hetzner-env-token", HcloudTokenEnvVar: "hcloud-env-token"},
+			want:        "inline-token",
+		},
+		{
+			name:        "context option wins over env vars",
+			optionsJson: `{"Context": "work"}`,
+			envVars:     map[string]string{"HETZNER_API_TOKEN": "hetzner-env-token", HcloudTokenEnvVar: "hcloud-env-token"},
+			want:        "work-token",
+		},
+		{
+			name:        "HETZNER_API_TOKEN wins over HCLOUD_TOKEN",
+			optionsJson: `{}`,
+			envVars:     map[string]string{"HETZNER_API_TOKEN": "hetzner-env-token", HcloudTokenEnvVar: "hcloud-env-token"},
+			want:        "hetzner-env-token",
+		},
+		{
+			name:        "HCLOUD_TOKEN wins over the config",
+			optionsJson: `{}`,
+			envVars:     map[string]string{HcloudTokenEnvVar: "hcloud-env-token", HcloudContextEnvVar: "work"},
+			want:        "hcloud-env-token",
+		},
+		{
+			name:        "HCLOUD_CONTEXT wins over the active context",
+			optionsJson: `{}`,
+			envVars:     map[string]string{HcloudContextEnvVar: "work"},
+			want:        "work-token",
+		},
+		{
+			name:        "active context",
+			optionsJson: `{}`,
+			want:        "personal-token",
+		},
+		{
+			name:        "unknown context",
+			optionsJson: `{"Context": "missing"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "context without token",
+			optionsJson: `{"Context": "empty"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "context without config",
+			optionsJson: `{"Context": "work"}`,
+			noConfig:    true,
+			wantErr:     true,
+		},
+		{
+			name:        "no token anywhere",
+			optionsJson: `{}`,
+			noConfig:    true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearTokenEnv(t)
+			if !tt.noConfig {
+				configPath := filepath.Join(t.TempDir(), "cli.toml")
+				err := os.WriteFile(configPath, []byte(testHcloudConfig), 0600)
+				if err != nil {
+					t.Fatalf("failed to write hcloud config: %s", err)
+				}
+				t.Setenv(HcloudConfigEnvVar, configPath)
+			}
+			for k, v := range tt.envVars {
+				t.Setenv(k, v)
+			}
+
+			got, err := ParseTargetOptions(tt.optionsJson)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTargetOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.APIToken != tt.want {
+				t.Errorf("expected token %q, got %q", tt.want, got.APIToken)
+			}
+		})
+	}
+}
+
+func TestReadHcloudConfig(t *testing.T) {
+	config, err := ReadHcloudConfig(filepath.Join(t.TempDir(), "cli.toml"))
+	if err != nil || config != nil {
+		t.Errorf("expected no config for a missing file, got %v, %v", config, err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "cli.toml")
+	err = os.WriteFile(configPath, []byte(`active_context = `), 0600)
+	if err != nil {
+		t.Fatalf("failed to write hcloud config: %s", err)
+	}
+	_, err = ReadHcloudConfig(configPath)
+	if err == nil {
+		t.Errorf("expected an error for an invalid config")
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxLabelNameLength is the length limit of label values and of label keys without their prefix.
+	maxLabelNameLength = 63
+	// maxLabelPrefixLength is the length limit of the optional DNS subdomain prefix of label keys.
+	maxLabelPrefixLength = 253
+)
+
+// reservedLabelPrefixes are used by Hetzner and by the ownership labels of the provider.
+var reservedLabelPrefixes = []string{"hetzner.cloud/", "daytona.io/"}
+
+var (
+	labelNamePattern   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+	labelPrefixPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// LabelMap parses the comma separated key=value pairs of the Labels option.
+func (o *TargetOptions) LabelMap() (map[string]string, error) {
+	labels := map[string]string{}
+	for _, label := range strings.Split(o.Labels, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+
+		key, value, err := ParseLabel(label)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := labels[key]; ok {
+			return nil, fmt.Errorf("duplicate label %q", key)
+		}
+		for _, prefix := range reservedLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return nil, fmt.Errorf("label %q uses the reserved prefix %s", key, prefix)
+			}
+		}
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// ParseLabel parses a key=value label and checks it against the Hetzner label syntax.
+func ParseLabel(label string) (string, string, error) {
+	key, value, ok := strings.Cut(label, "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid label %q, expected key=value", label)
+	}
+
+	name := key
+	if prefix, keyName, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) > maxLabelPrefixLength || !labelPrefixPattern.MatchString(prefix) {
+			return "", "", fmt.Errorf("invalid label key %q, the prefix must be a DNS subdomain", key)
+		}
+		name = keyName
+	}
+	if len(name) > maxLabelNameLength || !labelNamePattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid label key %q, it must be at most %d alphanumeric characters, '-', '_' or '.' and start and end with an alphanumeric character", key, maxLabelNameLength)
+	}
+
+	if value != "" && (len(value) > maxLabelNameLength || !labelNamePattern.MatchString(value)) {
+		return "", "", fmt.Errorf("invalid label value %q, it must be at most %d alphanumeric characters, '-', '_' or '.' and start and end with an alphanumeric character", value, maxLabelNameLength)
+	}
+
+	return key, value, nil
+}
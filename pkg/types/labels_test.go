@@ -0,0 +1,69 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			labels: "",
+			want:   map[string]string{},
+		},
+		{
+			name:   "cost allocation labels",
+			labels: "team=core, costcenter=42,env=dev,",
+			want:   map[string]string{"team": "core", "costcenter": "42", "env": "dev"},
+		},
+		{
+			name:   "prefixed key and empty value",
+			labels: "example.com/owner=alice,temporary=",
+			want:   map[string]string{"example.com/owner": "alice", "temporary": ""},
+		},
+		{
+			name:    "missing value separator",
+			labels:  "team",
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			labels:  "team=core team",
+			wantErr: true,
+		},
+		{
+			name:    "key ending in a dash",
+			labels:  "team-=core",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate key",
+			labels:  "env=dev,env=prod",
+			wantErr: true,
+		},
+		{
+			name:    "reserved prefix",
+			labels:  "daytona.io/workspace-id=123",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &TargetOptions{Labels: tt.labels}
+			got, err := options.LabelMap()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LabelMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LabelMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
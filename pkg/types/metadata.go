@@ -4,22 +4,122 @@ import (
 	"github.com/hetznercloud/hcloud-go/hcloud"
 )
 
+// WorkspaceMetadataVersion is the current version of the WorkspaceMetadata JSON layout.
+// Metadata written before versioning was introduced has a Version of 0.
+const WorkspaceMetadataVersion = 1
+
 type WorkspaceMetadata struct {
-	ServerID     int
-	ServerName   string
-	ServerMemory float32
-	Architecture string
-	Location     string
-	Created      string
+	Version        int
+	ServerID       int
+	ServerName     string
+	ServerType     string `json:",omitempty"`
+	ServerMemory   float32
+	Cores          int `json:",omitempty"`
+	Disk           int `json:",omitempty"`
+	Architecture   string
+	Status         string `json:",omitempty"`
+	Location       string
+	Datacenter     string   `json:",omitempty"`
+	PublicIPv4     string   `json:",omitempty"`
+	PublicIPv6     string   `json:",omitempty"`
+	PrivateIPs     []string `json:",omitempty"`
+	PlacementGroup string   `json:",omitempty"`
+	Protection     ServerProtection
+	Volumes        []VolumeMetadata   `json:",omitempty"`
+	Labels         map[string]string  `json:",omitempty"`
+	Cost           *CostEstimate      `json:",omitempty"`
+	BackupWindow   string             `json:",omitempty"`
+	Snapshots      []SnapshotMetadata `json:",omitempty"`
+	Created        string
+}
+
+type VolumeMetadata struct {
+	ID               int
+	Name             string
+	Size             int
+	DeleteProtection bool
+}
+
+// ServerProtection is the Hetzner protection of a workspace server.
+type ServerProtection struct {
+	Delete  bool
+	Rebuild bool
 }
 
 // ToWorkspaceMetadata converts and maps values from an *hcloud.Server to a WorkspaceMetadata.
-func ToWorkspaceMetadata(server *hcloud.Server) WorkspaceMetadata {
-	return WorkspaceMetadata{
+// Volumes and pricing are optional and are used to fill in the volume sizes and the cost of the workspace.
+func ToWorkspaceMetadata(server *hcloud.Server, volumes []*hcloud.Volume, pricing *hcloud.Pricing) WorkspaceMetadata {
+	metadata := WorkspaceMetadata{
+		Version:      WorkspaceMetadataVersion,
 		ServerID:     server.ID,
 		ServerName:   server.Name,
-		ServerMemory: server.ServerType.Memory,
-		Architecture: string(server.ServerType.Architecture),
-		Created:      server.Created.String(),
+		Status:       string(server.Status),
+		Labels:       server.Labels,
+		BackupWindow: server.BackupWindow,
+		Protection: ServerProtection{
+			Delete:  server.Protection.Delete,
+			Rebuild: server.Protection.Rebuild,
+		},
+		Created: server.Created.String(),
+	}
+
+	if server.ServerType != nil {
+		metadata.ServerType = server.ServerType.Name
+		metadata.ServerMemory = server.ServerType.Memory
+		metadata.Cores = server.ServerType.Cores
+		metadata.Disk = server.ServerType.Disk
+		metadata.Architecture = string(server.ServerType.Architecture)
+	}
+
+	if server.Datacenter != nil {
+		metadata.Datacenter = server.Datacenter.Name
+		if server.Datacenter.Location != nil {
+			metadata.Location = server.Datacenter.Location.Name
+		}
+	}
+
+	if server.PublicNet.IPv4.IP != nil {
+		metadata.PublicIPv4 = server.PublicNet.IPv4.IP.String()
 	}
+	if server.PublicNet.IPv6.Network != nil {
+		metadata.PublicIPv6 = server.PublicNet.IPv6.Network.String()
+	} else if server.PublicNet.IPv6.IP != nil {
+		metadata.PublicIPv6 = server.PublicNet.IPv6.IP.String()
+	}
+
+	if server.PlacementGroup != nil {
+		metadata.PlacementGroup = server.PlacementGroup.Name
+	}
+
+	for _, privateNet := range server.PrivateNet {
+		if privateNet.IP != nil {
+			metadata.PrivateIPs = append(metadata.PrivateIPs, privateNet.IP.String())
+		}
+	}
+
+	volumesById := map[int]*hcloud.Volume{}
+	for _, volume := range volumes {
+		volumesById[volume.ID] = volume
+	}
+	for _, serverVolume := range server.Volumes {
+		volume, ok := volumesById[serverVolume.ID]
+		if !ok {
+			volume = serverVolume
+		}
+		metadata.Volumes = append(metadata.Volumes, VolumeMetadata{
+			ID:               volume.ID,
+			Name:             volume.Name,
+			Size:             volume.Size,
+			DeleteProtection: volume.Protection.Delete,
+		})
+	}
+
+	if pricing != nil && server.ServerType != nil {
+		cost, err := EstimateServerCost(pricing, server, volumes)
+		if err == nil {
+			metadata.Cost = cost
+		}
+	}
+
+	return metadata
 }
@@ -0,0 +1,173 @@
+package types
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func fixtureServer() *hcloud.Server {
+	_, ipv6Net, _ := net.ParseCIDR("2a01:4f8:c012:abcd::/64")
+	fsn1 := &hcloud.Location{Name: "fsn1"}
+
+	return &hcloud.Server{
+		ID:      42,
+		Name:    "daytona-123",
+		Status:  hcloud.ServerStatusRunning,
+		Created: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC),
+		PublicNet: hcloud.ServerPublicNet{
+			IPv4: hcloud.ServerPublicNetIPv4{ID: 100, IP: net.ParseIP("203.0.113.10")},
+			IPv6: hcloud.ServerPublicNetIPv6{IP: ipv6Net.IP, Network: ipv6Net},
+		},
+		PrivateNet: []hcloud.ServerPrivateNet{
+			{IP: net.ParseIP("10.0.0.2")},
+		},
+		ServerType: &hcloud.ServerType{
+			Name:         "cpx11",
+			Cores:        2,
+			Memory:       2,
+			Disk:         40,
+			Architecture: hcloud.ArchitectureX86,
+		},
+		Datacenter: &hcloud.Datacenter{
+			Name:     "fsn1-dc14",
+			Location: fsn1,
+		},
+		Labels:  map[string]string{"daytona.io/workspace-id": "123"},
+		Volumes: []*hcloud.Volume{{ID: 7}},
+	}
+}
+
+func fixturePricing() *hcloud.Pricing {
+	return &hcloud.Pricing{
+		Volume: hcloud.VolumePricing{
+			PerGBMonthly: hcloud.Price{Currency: "EUR", Gross: "0.0524"},
+		},
+		ServerBackup: hcloud.ServerBackupPricing{Percentage: "20.0000"},
+		PrimaryIPs: []hcloud.PrimaryIPPricing{
+			{
+				Type: "ipv4",
+				Pricings: []hcloud.PrimaryIPTypePricing{
+					{
+						Location: "fsn1",
+						Hourly:   hcloud.PrimaryIPPrice{Gross: "0.0008"},
+						Monthly:  hcloud.PrimaryIPPrice{Gross: "0.5000"},
+					},
+				},
+			},
+		},
+		ServerTypes: []hcloud.ServerTypePricing{
+			{
+				ServerType: &hcloud.ServerType{Name: "cpx11"},
+				Pricings: []hcloud.ServerTypeLocationPricing{
+					{
+						Location: &hcloud.Location{Name: "nbg1"},
+						Hourly:   hcloud.Price{Currency: "EUR", Gross: "0.0100"},
+						Monthly:  hcloud.Price{Currency: "EUR", Gross: "5.0000"},
+					},
+					{
+						Location: &hcloud.Location{Name: "fsn1"},
+						Hourly:   hcloud.Price{Currency: "EUR", Gross: "0.0080"},
+						Monthly:  hcloud.Price{Currency: "EUR", Gross: "4.8500"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestToWorkspaceMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  *hcloud.Server
+		volumes []*hcloud.Volume
+		pricing *hcloud.Pricing
+		want    WorkspaceMetadata
+	}{
+		{
+			name:    "Server with volumes and pricing",
+			server:  fixtureServer(),
+			volumes: []*hcloud.Volume{{ID: 7, Name: "daytona-123", Size: 20}},
+			pricing: fixturePricing(),
+			want: WorkspaceMetadata{
+				Version:      WorkspaceMetadataVersion,
+				ServerID:     42,
+				ServerName:   "daytona-123",
+				ServerType:   "cpx11",
+				ServerMemory: 2,
+				Cores:        2,
+				Disk:         40,
+				Architecture: "x86",
+				Status:       "running",
+				Location:     "fsn1",
+				Datacenter:   "fsn1-dc14",
+				PublicIPv4:   "203.0.113.10",
+				PublicIPv6:   "2a01:4f8:c012:abcd::/64",
+				PrivateIPs:   []string{"10.0.0.2"},
+				Volumes:      []VolumeMetadata{{ID: 7, Name: "daytona-123", Size: 20}},
+				Labels:       map[string]string{"daytona.io/workspace-id": "123"},
+				Created:      "2024-09-01 12:00:00 +0000 UTC",
+			},
+		},
+		{
+			name:   "Server without volume details and pricing",
+			server: fixtureServer(),
+			want: WorkspaceMetadata{
+				Version:      WorkspaceMetadataVersion,
+				ServerID:     42,
+				ServerName:   "daytona-123",
+				ServerType:   "cpx11",
+				ServerMemory: 2,
+				Cores:        2,
+				Disk:         40,
+				Architecture: "x86",
+				Status:       "running",
+				Location:     "fsn1",
+				Datacenter:   "fsn1-dc14",
+				PublicIPv4:   "203.0.113.10",
+				PublicIPv6:   "2a01:4f8:c012:abcd::/64",
+				PrivateIPs:   []string{"10.0.0.2"},
+				Volumes:      []VolumeMetadata{{ID: 7}},
+				Labels:       map[string]string{"daytona.io/workspace-id": "123"},
+				Created:      "2024-09-01 12:00:00 +0000 UTC",
+			},
+		},
+		{
+			name: "Server with missing nested objects",
+			server: &hcloud.Server{
+				ID:      1,
+				Name:    "daytona-456",
+				Status:  hcloud.ServerStatusOff,
+				Created: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC),
+			},
+			pricing: fixturePricing(),
+			want: WorkspaceMetadata{
+				Version:    WorkspaceMetadataVersion,
+				ServerID:   1,
+				ServerName: "daytona-456",
+				Status:     "off",
+				Created:    "2024-09-01 12:00:00 +0000 UTC",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.pricing != nil && tt.server.ServerType != nil {
+				cost, err := EstimateServerCost(tt.pricing, tt.server, tt.volumes)
+				if err != nil {
+					t.Fatalf("EstimateServerCost() error = %v", err)
+				}
+				tt.want.Cost = cost
+			}
+
+			got := ToWorkspaceMetadata(tt.server, tt.volumes, tt.pricing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToWorkspaceMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
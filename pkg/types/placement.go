@@ -0,0 +1,44 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// MaxPlacementGroupServers is the number of servers Hetzner allows in a spread placement group.
+const MaxPlacementGroupServers = 10
+
+// SelectPlacementGroup returns the oldest placement group that has room for one more server, or nil if all
+// of them are full.
+func SelectPlacementGroup(groups []*hcloud.PlacementGroup) *hcloud.PlacementGroup {
+	sorted := append([]*hcloud.PlacementGroup{}, groups...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, group := range sorted {
+		if len(group.Servers) < MaxPlacementGroupServers {
+			return group
+		}
+	}
+
+	return nil
+}
+
+// NextPlacementGroupName returns the name of the next managed placement group of a target. Managed groups
+// are numbered so that a new group can be created when all existing ones are full.
+func NextPlacementGroupName(target string, groups []*hcloud.PlacementGroup) string {
+	names := map[string]struct{}{}
+	for _, group := range groups {
+		names[group.Name] = struct{}{}
+	}
+
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("daytona-%s-%d", target, i)
+		if _, ok := names[name]; !ok {
+			return name
+		}
+	}
+}
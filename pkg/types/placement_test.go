@@ -0,0 +1,69 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func TestSelectPlacementGroup(t *testing.T) {
+	full := make([]int, MaxPlacementGroupServers)
+
+	tests := []struct {
+		name   string
+		groups []*hcloud.PlacementGroup
+		wantID int
+	}{
+		{
+			name: "no groups",
+		},
+		{
+			name: "oldest group with room",
+			groups: []*hcloud.PlacementGroup{
+				{ID: 3, Servers: []int{1}},
+				{ID: 1, Servers: full},
+				{ID: 2, Servers: []int{2, 3}},
+			},
+			wantID: 2,
+		},
+		{
+			name: "all groups full",
+			groups: []*hcloud.PlacementGroup{
+				{ID: 1, Servers: full},
+				{ID: 2, Servers: full},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectPlacementGroup(tt.groups)
+			if tt.wantID == 0 {
+				if got != nil {
+					t.Errorf("expected no group, got %d", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.wantID {
+				t.Errorf("expected group %d, got %v", tt.wantID, got)
+			}
+		})
+	}
+}
+
+func TestNextPlacementGroupName(t *testing.T) {
+	groups := []*hcloud.PlacementGroup{
+		{Name: "daytona-team-1"},
+		{Name: "daytona-team-3"},
+	}
+
+	got := NextPlacementGroupName("team", groups)
+	if got != "daytona-team-2" {
+		t.Errorf("expected daytona-team-2, got %s", got)
+	}
+
+	got = NextPlacementGroupName("team", nil)
+	if got != "daytona-team-1" {
+		t.Errorf("expected daytona-team-1, got %s", got)
+	}
+}
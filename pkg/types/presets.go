@@ -0,0 +1,181 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// PresetsFileName is the name of the user-editable presets file in the provider base path.
+const PresetsFileName = "presets.json"
+
+// Preset is a target that is created when the provider is installed. Only the options that are set are
+// stored in the target, so the API token falls back to HETZNER_API_TOKEN unless the preset sets one.
+type Preset struct {
+	Name    string                 `json:"name"`
+	Options map[string]interface{} `json:"options"`
+}
+
+// BuiltinPresets covers the common server configurations in every Hetzner region.
+var BuiltinPresets = []Preset{
+	{
+		Name: "hetzner-fsn1-small",
+		Options: map[string]interface{}{
+			"Location":    "fsn1",
+			"Server Type": "cx22",
+		},
+	},
+	{
+		Name: "hetzner-nbg1-arm",
+		Options: map[string]interface{}{
+			"Location":    "nbg1",
+			"Server Type": "cax11",
+		},
+	},
+	{
+		Name: "hetzner-ash-dedicated",
+		Options: map[string]interface{}{
+			"Location":    "ash",
+			"Server Type": "ccx13",
+		},
+	},
+	{
+		Name: "hetzner-hil-us-west",
+		Options: map[string]interface{}{
+			"Location":    "hil",
+			"Server Type": "cpx21",
+		},
+	},
+}
+
+// envPresetOptions maps the env vars of the env preset to target options.
+var envPresetOptions = []struct {
+	envVar string
+	option string
+	isInt  bool
+}{
+	{"HETZNER_LOCATION", "Location", false},
+	{"HETZNER_FALLBACK_LOCATIONS", "Fallback Locations", false},
+	{"HETZNER_SERVER_TYPE", "Server Type", false},
+	{"HETZNER_DISK_IMAGE", "Disk Image", false},
+	{"HETZNER_DISK_SIZE", "Disk Size", true},
+}
+
+// GetPresets returns the builtin presets, the presets of the presets file and the env preset. Presets
+// with the same name replace earlier ones.
+func GetPresets(presetsFilePath string) ([]Preset, error) {
+	presets := append([]Preset{}, BuiltinPresets...)
+
+	filePresets, err := ReadPresetsFile(presetsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	presets = mergePresets(presets, filePresets...)
+
+	envPreset, err := GetEnvPreset()
+	if err != nil {
+		return nil, err
+	}
+	if envPreset != nil {
+		presets = mergePresets(presets, *envPreset)
+	}
+
+	return presets, nil
+}
+
+// ReadPresetsFile reads a JSON list of presets. A missing file contains no presets.
+func ReadPresetsFile(path string) ([]Preset, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var presets []Preset
+	err = json.Unmarshal(content, &presets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid presets file %s: %w", path, err)
+	}
+
+	for _, preset := range presets {
+		if preset.Name == "" {
+			return nil, fmt.Errorf("invalid presets file %s: every preset needs a name", path)
+		}
+	}
+
+	return presets, nil
+}
+
+// GetEnvPreset returns the preset described by the HETZNER_* env vars, or nil if none of them is set.
+// It is named after HETZNER_PRESET_NAME and defaults to hetzner-env.
+func GetEnvPreset() (*Preset, error) {
+	options := map[string]interface{}{}
+	for _, envOption := range envPresetOptions {
+		value, ok := os.LookupEnv(envOption.envVar)
+		if !ok || value == "" {
+			continue
+		}
+
+		if !envOption.isInt {
+			options[envOption.option] = value
+			continue
+		}
+
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", envOption.envVar, value, err)
+		}
+		options[envOption.option] = intValue
+	}
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	name := os.Getenv("HETZNER_PRESET_NAME")
+	if name == "" {
+		name = "hetzner-env"
+	}
+
+	return &Preset{Name: name, Options: options}, nil
+}
+
+// TargetOptions returns the options of the preset with the defaults of the target manifest.
+func (p *Preset) TargetOptions() (*TargetOptions, error) {
+	optionsJson, err := json.Marshal(p.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	targetOptions, err := decodeTargetOptions(string(optionsJson))
+	if err != nil {
+		return nil, fmt.Errorf("invalid options for preset %s: %w", p.Name, err)
+	}
+
+	return targetOptions, nil
+}
+
+func mergePresets(presets []Preset, overrides ...Preset) []Preset {
+	for _, override := range overrides {
+		replaced := false
+		for i := range presets {
+			if presets[i].Name == override.Name {
+				presets[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			presets = append(presets, override)
+		}
+	}
+	return presets
+}
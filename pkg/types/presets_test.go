@@ -0,0 +1,125 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetPresets(t *testing.T) {
+	presetsFile := filepath.Join(t.TempDir(), PresetsFileName)
+	err := os.WriteFile(presetsFile, []byte(`[
+		{"name": "hetzner-nbg1-arm", "options": {"Location": "nbg1", "Server Type": "cax21"}},
+		{"name": "team-large", "options": {"Location": "hel1", "Server Type": "cpx41", "Disk Size": 100}}
+	]`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write presets file: %s", err)
+	}
+
+	t.Setenv("HETZNER_LOCATION", "sin")
+	t.Setenv("HETZNER_DISK_SIZE", "40")
+
+	presets, err := GetPresets(presetsFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	byName := map[string]Preset{}
+	for _, preset := range presets {
+		byName[preset.Name] = preset
+	}
+
+	if len(presets) != len(BuiltinPresets)+2 {
+		t.Errorf("expected %d presets, got %d", len(BuiltinPresets)+2, len(presets))
+	}
+	if byName["hetzner-nbg1-arm"].Options["Server Type"] != "cax21" {
+		t.Errorf("expected the presets file to override the builtin preset, got %v", byName["hetzner-nbg1-arm"].Options)
+	}
+	if byName["team-large"].Options["Location"] != "hel1" {
+		t.Errorf("expected the team-large preset from the presets file, got %v", byName["team-large"].Options)
+	}
+
+	envPreset, ok := byName["hetzner-env"]
+	if !ok {
+		t.Fatalf("expected the env preset")
+	}
+	if envPreset.Options["Location"] != "sin" || envPreset.Options["Disk Size"] != 40 {
+		t.Errorf("unexpected env preset options %v", envPreset.Options)
+	}
+}
+
+func TestGetPresetsWithoutFile(t *testing.T) {
+	presets, err := GetPresets(filepath.Join(t.TempDir(), PresetsFileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(presets) != len(BuiltinPresets) {
+		t.Errorf("expected only the builtin presets, got %d", len(presets))
+	}
+}
+
+func TestReadPresetsFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid json", `{"name": "x"`},
+		{"missing name", `[{"options": {"Location": "fsn1"}}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presetsFile := filepath.Join(t.TempDir(), PresetsFileName)
+			err := os.WriteFile(presetsFile, []byte(tt.content), 0644)
+			if err != nil {
+				t.Fatalf("failed to write presets file: %s", err)
+			}
+
+			_, err = ReadPresetsFile(presetsFile)
+			if err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetEnvPreset(t *testing.T) {
+	preset, err := GetEnvPreset()
+	if err != nil || preset != nil {
+		t.Fatalf("expected no env preset, got %v, %v", preset, err)
+	}
+
+	t.Setenv("HETZNER_PRESET_NAME", "my-preset")
+	t.Setenv("HETZNER_SERVER_TYPE", "cpx31")
+	preset, err = GetEnvPreset()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if preset.Name != "my-preset" || preset.Options["Server Type"] != "cpx31" {
+		t.Errorf("unexpected env preset %v", preset)
+	}
+
+	t.Setenv("HETZNER_DISK_SIZE", "large")
+	_, err = GetEnvPreset()
+	if err == nil {
+		t.Errorf("expected an error for an invalid disk size")
+	}
+}
+
+func TestPresetTargetOptions(t *testing.T) {
+	preset := Preset{Name: "disk-only", Options: map[string]interface{}{"Disk Size": 50}}
+
+	opts, err := preset.TargetOptions()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if opts.Location != "fsn1" || opts.ServerType != "cpx11" || opts.DiskSize != 50 {
+		t.Errorf("expected manifest defaults and the preset disk size, got %+v", opts)
+	}
+
+	preset = Preset{Name: "invalid", Options: map[string]interface{}{"Disk Size": "large"}}
+	_, err = preset.TargetOptions()
+	if err == nil {
+		t.Errorf("expected an error for invalid options")
+	}
+}
@@ -0,0 +1,213 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/daytonaio/daytona/pkg/provider"
+)
+
+// schemaVersionOption is the key of the schema version in the target options JSON. Targets saved by the
+// Daytona CLI do not contain it and are treated as version 0, so every migration must be safe to run on
+// options that are already in the current format.
+const schemaVersionOption = "Schema Version"
+
+// targetOptionsMigration migrates the raw target options from one schema version to the next.
+type targetOptionsMigration func(options map[string]json.RawMessage) error
+
+// targetOptionsMigrations holds the migration from version i to version i+1 at index i.
+var targetOptionsMigrations = []targetOptionsMigration{
+	migrateTargetOptionsV0,
+}
+
+// TargetOptionsSchemaVersion is the current version of the target options schema.
+var TargetOptionsSchemaVersion = len(targetOptionsMigrations)
+
+// decodeTargetOptions migrates the target options to the current schema version, applies the defaults of the
+// target manifest to missing options and decodes them. Unknown options are returned as warnings.
+func decodeTargetOptions(optionsJson string) (*TargetOptions, error) {
+	var options map[string]json.RawMessage
+	err := json.Unmarshal([]byte(optionsJson), &options)
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = map[string]json.RawMessage{}
+	}
+
+	err = migrateTargetOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := unknownOptionWarnings(options)
+
+	err = applyManifestDefaults(options, GetTargetManifest())
+	if err != nil {
+		return nil, err
+	}
+
+	migratedJson, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var targetOptions TargetOptions
+	err = json.Unmarshal(migratedJson, &targetOptions)
+	if err != nil {
+		return nil, err
+	}
+	targetOptions.Warnings = warnings
+
+	return &targetOptions, nil
+}
+
+func migrateTargetOptions(options map[string]json.RawMessage) error {
+	version := 0
+	if rawVersion, ok := options[schemaVersionOption]; ok {
+		err := json.Unmarshal(rawVersion, &version)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", schemaVersionOption, err)
+		}
+	}
+
+	if version > TargetOptionsSchemaVersion {
+		return fmt.Errorf("target options schema version %d is newer than the supported version %d, update the provider", version, TargetOptionsSchemaVersion)
+	}
+
+	for ; version < TargetOptionsSchemaVersion; version++ {
+		err := targetOptionsMigrations[version](options)
+		if err != nil {
+			return fmt.Errorf("failed to migrate target options from schema version %d: %w", version, err)
+		}
+	}
+
+	options[schemaVersionOption] = json.RawMessage(fmt.Sprint(TargetOptionsSchemaVersion))
+	return nil
+}
+
+// migrateTargetOptionsV0 repairs targets that were saved with the "nbg1," location suggestion.
+func migrateTargetOptionsV0(options map[string]json.RawMessage) error {
+	rawLocation, ok := options["Location"]
+	if !ok {
+		return nil
+	}
+
+	var location string
+	err := json.Unmarshal(rawLocation, &location)
+	if err != nil {
+		return fmt.Errorf("invalid Location: %w", err)
+	}
+
+	location = strings.Trim(location, ", ")
+	options["Location"], err = json.Marshal(location)
+	return err
+}
+
+// applyManifestDefaults sets the default value of every manifest property that is missing from the options.
+func applyManifestDefaults(options map[string]json.RawMessage, manifest *provider.ProviderTargetManifest) error {
+	for name, property := range *manifest {
+		if _, ok := options[name]; ok || property.DefaultValue == "" {
+			continue
+		}
+
+		value := json.RawMessage(property.DefaultValue)
+		switch property.Type {
+		case provider.ProviderTargetPropertyTypeString, provider.ProviderTargetPropertyTypeOption, provider.ProviderTargetPropertyTypeFilePath:
+			quoted, err := json.Marshal(property.DefaultValue)
+			if err != nil {
+				return err
+			}
+			value = quoted
+		}
+
+		if !json.Valid(value) {
+			return fmt.Errorf("invalid default value %q for %s", property.DefaultValue, name)
+		}
+		options[name] = value
+	}
+
+	return nil
+}
+
+// unknownOptionWarnings returns a warning for every option that is not part of the schema, with a
+// suggestion when the option looks like a misspelled known option.
+func unknownOptionWarnings(options map[string]json.RawMessage) []string {
+	known := targetOptionNames()
+
+	var warnings []string
+	for name := range options {
+		if _, ok := known[name]; ok {
+			continue
+		}
+
+		warning := fmt.Sprintf("unknown target option %q is ignored", name)
+		if suggestion := suggestOptionName(name, known); suggestion != "" {
+			warning = fmt.Sprintf("unknown target option %q is ignored, did you mean %q?", name, suggestion)
+		}
+		warnings = append(warnings, warning)
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// targetOptionNames returns the JSON names of all target options.
+func targetOptionNames() map[string]struct{} {
+	names := map[string]struct{}{}
+
+	optionsType := reflect.TypeOf(TargetOptions{})
+	for i := 0; i < optionsType.NumField(); i++ {
+		name, _, _ := strings.Cut(optionsType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = struct{}{}
+		}
+	}
+
+	return names
+}
+
+func suggestOptionName(name string, known map[string]struct{}) string {
+	normalized := normalizeOptionName(name)
+
+	suggestion := ""
+	bestDistance := 3
+	for knownName := range known {
+		distance := levenshtein(normalized, normalizeOptionName(knownName))
+		if distance < bestDistance || (distance == bestDistance && knownName < suggestion) {
+			suggestion = knownName
+			bestDistance = distance
+		}
+	}
+
+	return suggestion
+}
+
+func normalizeOptionName(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name))
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestDecodeTargetOptionsMigration(t *testing.T) {
+	tests := []struct {
+		name         string
+		optionsJson  string
+		wantLocation string
+		wantErr      bool
+	}{
+		{
+			name:         "version 0 location from the old suggestion",
+			optionsJson:  `{"Location": "nbg1,"}`,
+			wantLocation: "nbg1",
+		},
+		{
+			name:         "current version",
+			optionsJson:  `{"Schema Version": 1, "Location": "hel1"}`,
+			wantLocation: "hel1",
+		},
+		{
+			name:        "newer version",
+			optionsJson: `{"Schema Version": 99, "Location": "hel1"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid version",
+			optionsJson: `{"Schema Version": "one"}`,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTargetOptions(tt.optionsJson)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeTargetOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Location != tt.wantLocation {
+				t.Errorf("expected location %q, got %q", tt.wantLocation, got.Location)
+			}
+			if got.SchemaVersion != TargetOptionsSchemaVersion {
+				t.Errorf("expected schema version %d, got %d", TargetOptionsSchemaVersion, got.SchemaVersion)
+			}
+		})
+	}
+}
+
+func TestDecodeTargetOptionsDefaults(t *testing.T) {
+	got, err := decodeTargetOptions(`{"Disk Size": 0, "Server Type": "cax11", "Stream Agent Log": true}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got.DiskSize != 0 {
+		t.Errorf("expected an explicit disk size to be kept, got %d", got.DiskSize)
+	}
+	if got.ServerType != "cax11" || !got.StreamAgentLog {
+		t.Errorf("expected explicit options to be kept, got %+v", got)
+	}
+	if got.Location != "fsn1" || got.DiskImage != "ubuntu-24.04" || got.BudgetWarningThreshold != 80 {
+		t.Errorf("expected manifest defaults for missing options, got %+v", got)
+	}
+}
+
+func TestUnknownOptionWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"location", `unknown target option "location" is ignored, did you mean "Location"?`},
+		{"ServerType", `unknown target option "ServerType" is ignored, did you mean "Server Type"?`},
+		{"Dsik Size", `unknown target option "Dsik Size" is ignored, did you mean "Disk Size"?`},
+		{"Firewall", `unknown target option "Firewall" is ignored`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTargetOptions(`{"` + tt.name + `": "value"}`)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(got.Warnings) != 1 || got.Warnings[0] != tt.want {
+				t.Errorf("expected warning %q, got %q", tt.want, got.Warnings)
+			}
+		})
+	}
+}
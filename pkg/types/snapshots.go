@@ -0,0 +1,87 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+// SnapshotReasonLabel holds why a workspace snapshot was taken, see the SnapshotReason constants.
+const SnapshotReasonLabel = "daytona.io/snapshot-reason"
+
+const (
+	SnapshotReasonOnDemand = "on-demand"
+	SnapshotReasonDestroy  = "destroy"
+)
+
+type SnapshotMetadata struct {
+	ID          int
+	Description string
+	Reason      string `json:",omitempty"`
+	Size        float32
+	Created     string
+	Age         string
+}
+
+// ToSnapshotMetadata converts the snapshots of a workspace to metadata, newest first, with their age at now.
+func ToSnapshotMetadata(snapshots []*hcloud.Image, now time.Time) []SnapshotMetadata {
+	var metadata []SnapshotMetadata
+	for _, snapshot := range newestFirst(snapshots) {
+		metadata = append(metadata, SnapshotMetadata{
+			ID:          snapshot.ID,
+			Description: snapshot.Description,
+			Reason:      snapshot.Labels[SnapshotReasonLabel],
+			Size:        snapshot.ImageSize,
+			Created:     snapshot.Created.String(),
+			Age:         FormatAge(now.Sub(snapshot.Created)),
+		})
+	}
+	return metadata
+}
+
+// SnapshotsToPrune returns the snapshots beyond the retention count, oldest last. A retention of 0 keeps all
+// snapshots.
+func SnapshotsToPrune(snapshots []*hcloud.Image, retention int) []*hcloud.Image {
+	if retention <= 0 || len(snapshots) <= retention {
+		return nil
+	}
+	return newestFirst(snapshots)[retention:]
+}
+
+// FormatAge formats a duration as days, hours and minutes, e.g. 3d4h, 5h12m or 7m.
+func FormatAge(age time.Duration) string {
+	if age < 0 {
+		age = 0
+	}
+
+	days := int(age / (24 * time.Hour))
+	hours := int(age % (24 * time.Hour) / time.Hour)
+	minutes := int(age % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func newestFirst(snapshots []*hcloud.Image) []*hcloud.Image {
+	sorted := append([]*hcloud.Image{}, snapshots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.After(sorted[j].Created)
+	})
+	return sorted
+}
+
+// NewestImage returns the most recently created image, or nil if there are none.
+func NewestImage(images []*hcloud.Image) *hcloud.Image {
+	if len(images) == 0 {
+		return nil
+	}
+	return newestFirst(images)[0]
+}
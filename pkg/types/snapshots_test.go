@@ -0,0 +1,92 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/hcloud"
+)
+
+func TestSnapshotsToPrune(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []*hcloud.Image{
+		{ID: 1, Created: now.Add(-72 * time.Hour)},
+		{ID: 3, Created: now.Add(-1 * time.Hour)},
+		{ID: 2, Created: now.Add(-24 * time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		retention int
+		wantIDs   []int
+	}{
+		{name: "keep all", retention: 0},
+		{name: "below retention", retention: 5},
+		{name: "keep newest two", retention: 2, wantIDs: []int{1}},
+		{name: "keep newest one", retention: 1, wantIDs: []int{2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIDs []int
+			for _, snapshot := range SnapshotsToPrune(snapshots, tt.retention) {
+				gotIDs = append(gotIDs, snapshot.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("expected %v, got %v", tt.wantIDs, gotIDs)
+			}
+		})
+	}
+}
+
+func TestToSnapshotMetadata(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []*hcloud.Image{
+		{ID: 1, Description: "old", ImageSize: 1.5, Created: now.Add(-50 * time.Hour), Labels: map[string]string{SnapshotReasonLabel: SnapshotReasonDestroy}},
+		{ID: 2, Description: "new", ImageSize: 2, Created: now.Add(-90 * time.Minute)},
+	}
+
+	got := ToSnapshotMetadata(snapshots, now)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(got))
+	}
+	if got[0].ID != 2 || got[0].Age != "1h30m" || got[0].Reason != "" {
+		t.Errorf("unexpected newest snapshot %+v", got[0])
+	}
+	if got[1].ID != 1 || got[1].Age != "2d2h" || got[1].Reason != SnapshotReasonDestroy {
+		t.Errorf("unexpected oldest snapshot %+v", got[1])
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		-time.Minute:                 "0m",
+		7 * time.Minute:              "7m",
+		5*time.Hour + 12*time.Minute: "5h12m",
+		76 * time.Hour:               "3d4h",
+	}
+
+	for age, want := range tests {
+		if got := FormatAge(age); got != want {
+			t.Errorf("FormatAge(%s) = %s, want %s", age, got, want)
+		}
+	}
+}
+
+func TestNewestImage(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if got := NewestImage(nil); got != nil {
+		t.Errorf("expected no image, got %d", got.ID)
+	}
+
+	got := NewestImage([]*hcloud.Image{
+		{ID: 1, Created: now.Add(-time.Hour)},
+		{ID: 2, Created: now},
+		{ID: 3, Created: now.Add(-2 * time.Hour)},
+	})
+	if got == nil || got.ID != 2 {
+		t.Errorf("expected image 2, got %v", got)
+	}
+}
@@ -1,7 +1,7 @@
 package types
 
 var (
-	locations   = []string{"fsn1", "nbg1,", "hel1", "ash", "hil", "sin"}
+	locations   = []string{"fsn1", "nbg1", "hel1", "ash", "hil", "sin"}
 	diskImages  = []string{"ubuntu-20.04", "ubuntu-22.04", "ubuntu-24.04", "debian-11", "debian-12", "centos-stream-9", "rocky-8", "rocky-9", "alma-8", "alma-9", "fedora-40"}
 	serverTypes = []string{"cpx11", "cpx21", "cpx31", "cpx41", "cpx51", "cax11", "cax21", "cax31", "cax41", "ccx13", "ccx23", "ccx33", "ccx43", "ccx53", "ccx63", "cx22", "cx32", "cx42", "cx52"}
 )
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -36,13 +38,13 @@ func TestParseTargetOptions(t *testing.T) {
 				"Server Type":"cpx11",
 				"API Token":"token"
 			}`,
-			want: &TargetOptions{
+			want: withDefaults(TargetOptions{
 				Location:   "fsn1",
 				DiskImage:  "ubuntu-22.04",
 				DiskSize:   20,
 				ServerType: "cpx11",
 				APIToken:   "token",
-			},
+			}),
 			wantErr: false,
 		},
 		{
@@ -56,13 +58,13 @@ func TestParseTargetOptions(t *testing.T) {
 			envVars: map[string]string{
 				"HETZNER_API_TOKEN": "token",
 			},
-			want: &TargetOptions{
+			want: withDefaults(TargetOptions{
 				Location:   "fsn1",
 				DiskImage:  "ubuntu-22.04",
 				DiskSize:   20,
 				ServerType: "cpx11",
 				APIToken:   "token",
-			},
+			}),
 			wantErr: false,
 		},
 		{
@@ -83,9 +85,13 @@ func TestParseTargetOptions(t *testing.T) {
 			envVars: map[string]string{
 				"HETZNER_API_TOKEN": "token",
 			},
-			want: &TargetOptions{
-				APIToken: "token",
-			},
+			want: withDefaults(TargetOptions{
+				Location:   "fsn1",
+				DiskImage:  "ubuntu-24.04",
+				DiskSize:   20,
+				ServerType: "cpx11",
+				APIToken:   "token",
+			}),
 			wantErr: false,
 		},
 		{
@@ -97,36 +103,44 @@ func TestParseTargetOptions(t *testing.T) {
 			envVars: map[string]string{
 				"HETZNER_API_TOKEN": "token",
 			},
-			want: &TargetOptions{
+			want: withDefaults(TargetOptions{
+				Location:   "fsn1",
+				DiskImage:  "ubuntu-24.04",
 				DiskSize:   30,
 				ServerType: "cpx22",
 				APIToken:   "token",
-			},
+			}),
 			wantErr: false,
 		},
 		{
-			name: "JSON with additional non-required fields",
+			name: "JSON with unknown fields reports warnings",
 			optionsJson: `{
 				"Location":"fsn1",
 				"Disk Image":"ubuntu-22.04",
 				"Disk Size":20,
 				"Server Type":"cpx11",
 				"API Token":"token",
-				"ExtraField": "extra-value"
+				"ExtraField": "extra-value",
+				"Disk_Size": 30
 			}`,
-			want: &TargetOptions{
+			want: withDefaults(TargetOptions{
 				Location:   "fsn1",
 				DiskImage:  "ubuntu-22.04",
 				DiskSize:   20,
 				ServerType: "cpx11",
 				APIToken:   "token",
-			},
+				Warnings: []string{
+					`unknown target option "Disk_Size" is ignored, did you mean "Disk Size"?`,
+					`unknown target option "ExtraField" is ignored`,
+				},
+			}),
 			wantErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			clearTokenEnv(t)
 			for k, v := range tt.envVars {
 				t.Setenv(k, v)
 			}
@@ -136,9 +150,52 @@ func TestParseTargetOptions(t *testing.T) {
 				t.Errorf("ParseTargetOptions() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if got != nil {
+				// The credential resolver is covered by the credentials tests.
+				got.credentials = nil
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("ParseTargetOptions() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+// withDefaults sets the schema version and the manifest defaults of the options that are not covered by a test.
+func withDefaults(options TargetOptions) *TargetOptions {
+	options.SchemaVersion = TargetOptionsSchemaVersion
+	options.BudgetWarningThreshold = 80
+	options.AgentReadyTimeout = 10
+	options.AgentProbeMaxInterval = 15
+	return &options
+}
+
+// clearTokenEnv hides the tokens and the hcloud CLI config of the machine running the tests.
+func clearTokenEnv(t *testing.T) {
+	for _, envVar := range []string{"HETZNER_API_TOKEN", HcloudTokenEnvVar, HcloudContextEnvVar} {
+		t.Setenv(envVar, "")
+		os.Unsetenv(envVar)
+	}
+	t.Setenv(HcloudConfigEnvVar, filepath.Join(t.TempDir(), "cli.toml"))
+}
+
+func TestForceDeletes(t *testing.T) {
+	tests := []struct {
+		forceDelete string
+		workspaceId string
+		want        bool
+	}{
+		{forceDelete: "", workspaceId: "abc", want: false},
+		{forceDelete: "abc", workspaceId: "abc", want: true},
+		{forceDelete: "def, abc", workspaceId: "abc", want: true},
+		{forceDelete: "def", workspaceId: "abc", want: false},
+		{forceDelete: "*", workspaceId: "abc", want: false},
+	}
+
+	for _, tt := range tests {
+		options := &TargetOptions{ForceDelete: tt.forceDelete}
+		if got := options.ForceDeletes(tt.workspaceId); got != tt.want {
+			t.Errorf("ForceDeletes(%q) with %q = %v, want %v", tt.workspaceId, tt.forceDelete, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,52 @@
 package types
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/daytonaio/daytona/pkg/provider"
 )
 
+var ErrTokenNotSet = errors.New("auth token not set in env/target options")
+
 type TargetOptions struct {
-	Location   string `json:"Location"`
-	DiskImage  string `json:"Disk Image"`
-	DiskSize   int    `json:"Disk Size"`
-	ServerType string `json:"Server Type"`
-	APIToken   string `json:"API Token"`
+	SchemaVersion          int     `json:"Schema Version"`
+	Location               string  `json:"Location"`
+	DiskImage              string  `json:"Disk Image"`
+	DiskSize               int     `json:"Disk Size"`
+	ServerType             string  `json:"Server Type"`
+	APIToken               string  `json:"API Token"`
+	APITokenFile           string  `json:"API Token File"`
+	APITokenCommand        string  `json:"API Token Command"`
+	Context                string  `json:"Context"`
+	MaxMonthlySpend        float64 `json:"Max Monthly Spend"`
+	BudgetLabel            string  `json:"Budget Label"`
+	Labels                 string  `json:"Labels"`
+	BudgetWarningThreshold float64 `json:"Budget Warning Threshold"`
+	FallbackLocations      string  `json:"Fallback Locations"`
+	ServerLimit            int     `json:"Server Limit"`
+	CoreLimit              int     `json:"Core Limit"`
+	VolumeLimit            int     `json:"Volume Limit"`
+	PlacementGroup         string  `json:"Placement Group"`
+	SpreadServers          bool    `json:"Spread Servers"`
+	Backups                bool    `json:"Backups"`
+	SnapshotOnDestroy      bool    `json:"Snapshot On Destroy"`
+	SnapshotRetention      int     `json:"Snapshot Retention"`
+	RestoreImage           string  `json:"Restore Image"`
+	Protected              bool    `json:"Protected"`
+	ForceDelete            string  `json:"Force Delete"`
+	AttachVolumes          string  `json:"Attach Volumes"`
+	AgentReadyTimeout      int     `json:"Agent Ready Timeout"`
+	AgentProbeMaxInterval  int     `json:"Agent Probe Max Interval"`
+	DiagnosticsSshKey      bool    `json:"Diagnostics SSH Key"`
+	StreamAgentLog         bool    `json:"Stream Agent Log"`
+
+	// Warnings holds problems found while parsing the options that do not prevent their use, e.g. unknown options.
+	Warnings []string `json:"-"`
+
+	// credentials resolved the APIToken and can resolve it again when it is rejected.
+	credentials *CredentialResolver
 }
 
 func GetTargetManifest() *provider.ProviderTargetManifest {
@@ -34,7 +67,7 @@ func GetTargetManifest() *provider.ProviderTargetManifest {
 		},
 		"Disk Size": provider.ProviderTargetProperty{
 			Type:         provider.ProviderTargetPropertyTypeInt,
-			Description:  "The size of the instance volume, in GB. Default is 20 GB.",
+			Description:  "The size of the instance volume, in GB, between 10 and 10240. Default is 20 GB.",
 			DefaultValue: "20",
 		},
 		"Server Type": provider.ProviderTargetProperty{
@@ -47,29 +80,206 @@ func GetTargetManifest() *provider.ProviderTargetManifest {
 		"API Token": provider.ProviderTargetProperty{
 			Type:        provider.ProviderTargetPropertyTypeString,
 			InputMasked: true,
-			Description: "If empty, token will be fetched from the HETZNER_API_TOKEN environment variable.",
+			Description: "If empty, the token is resolved from the API Token File, API Token Command or Context options,\n" +
+				"the HETZNER_API_TOKEN or HCLOUD_TOKEN environment variables, or the hcloud CLI config, in this order.",
+		},
+		"API Token File": provider.ProviderTargetProperty{
+			Type:        provider.ProviderTargetPropertyTypeString,
+			Description: "Path of a file that contains the API token, e.g. a mounted secret. Used if API Token is empty.",
+		},
+		"API Token Command": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Shell command that prints the API token, e.g. op read op://vault/hetzner/token.\n" +
+				"Used if API Token and API Token File are empty. The token is cached in memory and the command runs again\n" +
+				"when the Hetzner API rejects the token.",
+		},
+		"Context": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "The name of an hcloud CLI context in ~/.config/hcloud/cli.toml to take the API token from.\n" +
+				"If empty, the HCLOUD_CONTEXT environment variable or the active context is used.",
+		},
+		"Max Monthly Spend": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeFloat,
+			Description: "The maximum gross monthly spend, in the Hetzner pricing currency, of all workspaces in the budget scope.\n" +
+				"Workspace creation is refused when the cap would be exceeded. Default is 0, which disables the cap.",
+			DefaultValue: "0",
+		},
+		"Budget Label": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Optional key=value label, e.g. owner=alice, that is added to created resources and defines the budget scope.\n" +
+				"If empty, the budget applies to all workspaces of the target.",
+		},
+		"Labels": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Optional comma separated key=value labels, e.g. team=core,costcenter=42,env=dev, that are added to\n" +
+				"every resource created for the workspace. Keys with the daytona.io/ or hetzner.cloud/ prefix are reserved.",
+		},
+		"Budget Warning Threshold": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeFloat,
+			Description:  "Percentage of the Max Monthly Spend at which a warning is written to the workspace log. Default is 80.",
+			DefaultValue: "80",
+		},
+		"Fallback Locations": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Comma separated, ordered list of locations to use when the server type is unavailable in the preferred location.\n" +
+				"https://docs.hetzner.com/cloud/general/locations",
+			Suggestions: locations,
+		},
+		"Server Limit": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeInt,
+			Description: "The server limit of the Hetzner project, as shown in the Cloud Console. Default is 0, which skips the check.\n" +
+				"The Hetzner API does not expose project limits, so they have to be provided here.",
+			DefaultValue: "0",
+		},
+		"Core Limit": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeInt,
+			Description:  "The dedicated and shared vCPU limit of the Hetzner project. Default is 0, which skips the check.",
+			DefaultValue: "0",
+		},
+		"Volume Limit": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeInt,
+			Description:  "The volume limit of the Hetzner project. Default is 0, which skips the check.",
+			DefaultValue: "0",
+		},
+		"Placement Group": provider.ProviderTargetProperty{
+			Type:        provider.ProviderTargetPropertyTypeString,
+			Description: "Optional name of an existing spread placement group to put the workspace servers in.",
+		},
+		"Spread Servers": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeBoolean,
+			Description: "Put the workspace servers in spread placement groups that the provider manages for the target,\n" +
+				"so that they run on different physical hosts. Default is false.",
+			DefaultValue: "false",
+		},
+		"Backups": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeBoolean,
+			Description:  "Enable the daily Hetzner backups of the workspace servers, at 20% of the server price. Default is false.",
+			DefaultValue: "false",
+		},
+		"Snapshot On Destroy": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeBoolean,
+			Description:  "Take a snapshot of the workspace server before it is deleted and keep its volume for Restore Image. Default is false.",
+			DefaultValue: "false",
+		},
+		"Snapshot Retention": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeInt,
+			Description:  "The number of snapshots kept per workspace. Older snapshots are deleted. Default is 0, which keeps all snapshots.",
+			DefaultValue: "0",
+		},
+		"Restore Image": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Optional ID of a snapshot or backup image, or a key=value label selecting the newest one, e.g.\n" +
+				"daytona.io/workspace-id=<id>, to restore the workspace from instead of installing it on the Disk Image.",
+		},
+		"Protected": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeBoolean,
+			Description: "Enable the Hetzner delete and rebuild protection of the workspace servers and volumes.\n" +
+				"Protected workspaces can only be deleted when listed in Force Delete. Default is false.",
+			DefaultValue: "false",
+		},
+		"Force Delete": provider.ProviderTargetProperty{
+			Type:        provider.ProviderTargetPropertyTypeString,
+			Description: "Comma separated IDs of protected workspaces that may be deleted.",
+		},
+		"Attach Volumes": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeString,
+			Description: "Optional comma separated existing volumes to attach, by name or ID, each with an optional mount path,\n" +
+				"e.g. datasets:/data,12345:/home/daytona/monorepo. Default mount path is /mnt/<volume>. The volumes are detached, not deleted,\n" +
+				"when the workspace is deleted.",
+		},
+		"Agent Ready Timeout": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeInt,
+			Description:  "How long to wait for the workspace agent to become ready, in minutes. Default is 10 minutes.",
+			DefaultValue: "10",
+		},
+		"Agent Probe Max Interval": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeInt,
+			Description: "The maximum interval between agent readiness probes, in seconds. Default is 15 seconds.\n" +
+				"Probes start at 1 second and back off exponentially up to this interval.",
+			DefaultValue: "15",
+		},
+		"Diagnostics SSH Key": provider.ProviderTargetProperty{
+			Type: provider.ProviderTargetPropertyTypeBoolean,
+			Description: "Add a provider generated SSH key for root to the server, so that the cloud-init and agent logs\n" +
+				"can be collected over the public IP when the workspace fails to join the tailnet. Default is false.",
+			DefaultValue: "false",
+		},
+		"Stream Agent Log": provider.ProviderTargetProperty{
+			Type:         provider.ProviderTargetPropertyTypeBoolean,
+			Description:  "Stream the Daytona agent log of the server into the workspace log while the workspace starts. Default is false.",
+			DefaultValue: "false",
 		},
 	}
 }
 
-// ParseTargetOptions parses the target options from the JSON string.
+// ParseTargetOptions parses the target options from the JSON string. Options of older schema versions are
+// migrated and missing options are set to the defaults of the target manifest. Invalid options are reported
+// as a *ValidationError with one *FieldError per option.
 func ParseTargetOptions(optionsJson string) (*TargetOptions, error) {
-	var targetOptions TargetOptions
-	err := json.Unmarshal([]byte(optionsJson), &targetOptions)
+	targetOptions, err := decodeTargetOptions(optionsJson)
 	if err != nil {
 		return nil, err
 	}
 
-	if targetOptions.APIToken == "" {
-		token, ok := os.LookupEnv("HETZNER_API_TOKEN")
-		if ok {
-			targetOptions.APIToken = token
-		}
+	err = targetOptions.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	targetOptions.credentials = targetOptions.Credentials()
+	targetOptions.APIToken, err = targetOptions.credentials.Token()
+	if err != nil {
+		return nil, err
 	}
 
 	if targetOptions.APIToken == "" {
-		return nil, fmt.Errorf("auth token not set in env/target options")
+		return nil, ErrTokenNotSet
+	}
+
+	return targetOptions, nil
+}
+
+// CredentialSources returns the sources of the API token in order of precedence:
+//  1. the API Token option
+//  2. the API Token File option
+//  3. the API Token Command option
+//  4. the named Context of the hcloud CLI config
+//  5. the HETZNER_API_TOKEN env var
+//  6. the HCLOUD_TOKEN env var
+//  7. the context named by HCLOUD_CONTEXT, or else the active context, of the hcloud CLI config
+func (o *TargetOptions) CredentialSources() []CredentialSource {
+	sources := []CredentialSource{
+		InlineCredentialSource{Token: o.APIToken},
+		FileCredentialSource{Path: o.APITokenFile},
+		CommandCredentialSource{Command: o.APITokenCommand},
+	}
+
+	if o.Context != "" {
+		return append(sources, HcloudContextCredentialSource{Context: o.Context})
 	}
 
-	return &targetOptions, nil
+	return append(sources,
+		EnvCredentialSource{EnvVar: "HETZNER_API_TOKEN"},
+		EnvCredentialSource{EnvVar: HcloudTokenEnvVar},
+		HcloudContextCredentialSource{Context: os.Getenv(HcloudContextEnvVar)},
+	)
+}
+
+// Credentials returns the resolver of the API token of the target.
+func (o *TargetOptions) Credentials() *CredentialResolver {
+	if o.credentials != nil {
+		return o.credentials
+	}
+	return NewCredentialResolver(o.CredentialSources()...)
+}
+
+// ForceDeletes returns whether the Force Delete option lists the protected workspace. Workspaces have to be
+// listed one by one, so that the option never disables the protection of the whole target.
+func (o *TargetOptions) ForceDeletes(workspaceId string) bool {
+	for _, id := range strings.Split(o.ForceDelete, ",") {
+		if id = strings.TrimSpace(id); id != "" && id == workspaceId {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinDiskSize and MaxDiskSize are the volume size limits of Hetzner, in GB.
+	MinDiskSize = 10
+	MaxDiskSize = 10240
+)
+
+// FieldError is a target option with an invalid value.
+type FieldError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidationError holds every invalid target option, so that all of them can be fixed at once.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Error()
+	}
+	return "invalid target options: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		errs[i] = fieldError
+	}
+	return errs
+}
+
+// Validate checks that the required options are set and that all options are within their ranges.
+func (o *TargetOptions) Validate() error {
+	validationError := &ValidationError{}
+	add := func(field string, value interface{}, reason string) {
+		validationError.Errors = append(validationError.Errors, &FieldError{Field: field, Value: value, Reason: reason})
+	}
+
+	if strings.TrimSpace(o.Location) == "" {
+		add("Location", o.Location, "a location is required")
+	}
+	if strings.TrimSpace(o.DiskImage) == "" {
+		add("Disk Image", o.DiskImage, "a disk image is required")
+	}
+	if strings.TrimSpace(o.ServerType) == "" {
+		add("Server Type", o.ServerType, "a server type is required")
+	}
+	if o.DiskSize < MinDiskSize || o.DiskSize > MaxDiskSize {
+		add("Disk Size", o.DiskSize, fmt.Sprintf("must be between %d and %d GB", MinDiskSize, MaxDiskSize))
+	}
+
+	if o.MaxMonthlySpend < 0 {
+		add("Max Monthly Spend", o.MaxMonthlySpend, "must not be negative")
+	}
+	if o.BudgetWarningThreshold < 0 || o.BudgetWarningThreshold > 100 {
+		add("Budget Warning Threshold", o.BudgetWarningThreshold, "must be a percentage between 0 and 100")
+	}
+	if o.BudgetLabel != "" {
+		_, _, err := ParseLabel(o.BudgetLabel)
+		if err != nil {
+			add("Budget Label", o.BudgetLabel, err.Error())
+		}
+	}
+	_, err := o.LabelMap()
+	if err != nil {
+		add("Labels", o.Labels, err.Error())
+	}
+
+	for _, limit := range []struct {
+		field string
+		value int
+	}{
+		{"Server Limit", o.ServerLimit},
+		{"Core Limit", o.CoreLimit},
+		{"Volume Limit", o.VolumeLimit},
+		{"Snapshot Retention", o.SnapshotRetention},
+	} {
+		if limit.value < 0 {
+			add(limit.field, limit.value, "must not be negative")
+		}
+	}
+
+	if o.RestoreImage != "" {
+		if _, err := strconv.Atoi(o.RestoreImage); err != nil {
+			_, _, err = ParseLabel(o.RestoreImage)
+			if err != nil {
+				add("Restore Image", o.RestoreImage, "must be an image ID or a key=value label")
+			}
+		}
+	}
+	_, err = o.VolumeAttachments()
+	if err != nil {
+		add("Attach Volumes", o.AttachVolumes, err.Error())
+	}
+
+	if o.PlacementGroup != "" && o.SpreadServers {
+		add("Placement Group", o.PlacementGroup, "cannot be combined with Spread Servers")
+	}
+
+	// A timeout of 0 uses the default of the provider.
+	if o.AgentReadyTimeout < 0 {
+		add("Agent Ready Timeout", o.AgentReadyTimeout, "must not be negative")
+	}
+	if o.AgentProbeMaxInterval < 0 {
+		add("Agent Probe Max Interval", o.AgentProbeMaxInterval, "must not be negative")
+	}
+
+	if len(validationError.Errors) > 0 {
+		return validationError
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *TargetOptions {
+		return withDefaults(TargetOptions{
+			Location:   "fsn1",
+			DiskImage:  "ubuntu-22.04",
+			DiskSize:   20,
+			ServerType: "cpx11",
+		})
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(o *TargetOptions)
+		wantFields []string
+	}{
+		{
+			name:   "valid",
+			modify: func(o *TargetOptions) {},
+		},
+		{
+			name:       "disk size below the Hetzner minimum",
+			modify:     func(o *TargetOptions) { o.DiskSize = 5 },
+			wantFields: []string{"Disk Size"},
+		},
+		{
+			name:       "disk size above the Hetzner maximum",
+			modify:     func(o *TargetOptions) { o.DiskSize = 20000 },
+			wantFields: []string{"Disk Size"},
+		},
+		{
+			name: "missing required options",
+			modify: func(o *TargetOptions) {
+				o.Location = ""
+				o.DiskImage = " "
+				o.ServerType = ""
+			},
+			wantFields: []string{"Location", "Disk Image", "Server Type"},
+		},
+		{
+			name: "out of range budget and limits",
+			modify: func(o *TargetOptions) {
+				o.MaxMonthlySpend = -1
+				o.BudgetWarningThreshold = 120
+				o.BudgetLabel = "=team"
+				o.Labels = "team"
+				o.CoreLimit = -4
+			},
+			wantFields: []string{"Max Monthly Spend", "Budget Warning Threshold", "Budget Label", "Labels", "Core Limit"},
+		},
+		{
+			name:   "restore image ID",
+			modify: func(o *TargetOptions) { o.RestoreImage = "123456" },
+		},
+		{
+			name:   "restore image label",
+			modify: func(o *TargetOptions) { o.RestoreImage = "daytona.io/workspace-id=abc" },
+		},
+		{
+			name:       "invalid restore image",
+			modify:     func(o *TargetOptions) { o.RestoreImage = "my snapshot" },
+			wantFields: []string{"Restore Image"},
+		},
+		{
+			name: "zero timeouts use the defaults",
+			modify: func(o *TargetOptions) {
+				o.AgentReadyTimeout = 0
+				o.AgentProbeMaxInterval = 0
+			},
+		},
+		{
+			name: "negative timeouts",
+			modify: func(o *TargetOptions) {
+				o.AgentReadyTimeout = -1
+				o.AgentProbeMaxInterval = -5
+			},
+			wantFields: []string{"Agent Ready Timeout", "Agent Probe Max Interval"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := valid()
+			tt.modify(options)
+
+			err := options.Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var validationError *ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expected a *ValidationError, got %v", err)
+			}
+			if len(validationError.Errors) != len(tt.wantFields) {
+				t.Fatalf("expected %d field errors, got %v", len(tt.wantFields), validationError.Errors)
+			}
+			for i, field := range tt.wantFields {
+				if validationError.Errors[i].Field != field {
+					t.Errorf("expected field error %d for %s, got %s", i, field, validationError.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTargetOptionsFieldError(t *testing.T) {
+	clearTokenEnv(t)
+
+	_, err := ParseTargetOptions(`{"Disk Size": 5, "API Token": "token"}`)
+
+	var fieldError *FieldError
+	if !errors.As(err, &fieldError) {
+		t.Fatalf("expected a *FieldError, got %v", err)
+	}
+	if fieldError.Field != "Disk Size" || fieldError.Value != 5 {
+		t.Errorf("expected Disk Size 5, got %s %v", fieldError.Field, fieldError.Value)
+	}
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// mountPathPattern restricts mount paths to characters that are safe in the boot script.
+var mountPathPattern = regexp.MustCompile(`^/[a-zA-Z0-9._/-]+$`)
+
+// VolumeAttachment is an existing Hetzner volume that is attached to the workspace server.
+type VolumeAttachment struct {
+	// Volume is the name or ID of the volume.
+	Volume string
+	// MountPath is where the volume is mounted on the server.
+	MountPath string
+}
+
+// VolumeAttachments parses the comma separated volume[:mount path] entries of the Attach Volumes option.
+// Without a mount path, a volume is mounted at /mnt/<volume>.
+func (o *TargetOptions) VolumeAttachments() ([]VolumeAttachment, error) {
+	var attachments []VolumeAttachment
+	volumes := map[string]struct{}{}
+	mountPaths := map[string]struct{}{}
+
+	for _, entry := range strings.Split(o.AttachVolumes, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		volume, mountPath, _ := strings.Cut(entry, ":")
+		volume = strings.TrimSpace(volume)
+		mountPath = strings.TrimSpace(mountPath)
+		if volume == "" {
+			return nil, fmt.Errorf("missing volume name or ID in %q", entry)
+		}
+		if mountPath == "" {
+			mountPath = "/mnt/" + volume
+		}
+		mountPath = path.Clean(mountPath)
+
+		if !mountPathPattern.MatchString(mountPath) || mountPath == "/" {
+			return nil, fmt.Errorf("invalid mount path %q, expected an absolute path of letters, digits, '.', '_', '-' and '/'", mountPath)
+		}
+		if _, ok := volumes[volume]; ok {
+			return nil, fmt.Errorf("volume %s is listed more than once", volume)
+		}
+		if _, ok := mountPaths[mountPath]; ok {
+			return nil, fmt.Errorf("mount path %s is used more than once", mountPath)
+		}
+		volumes[volume] = struct{}{}
+		mountPaths[mountPath] = struct{}{}
+
+		attachments = append(attachments, VolumeAttachment{Volume: volume, MountPath: mountPath})
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVolumeAttachments(t *testing.T) {
+	tests := []struct {
+		name          string
+		attachVolumes string
+		want          []VolumeAttachment
+		wantErr       bool
+	}{
+		{
+			name:          "empty",
+			attachVolumes: "",
+		},
+		{
+			name:          "names, IDs and mount paths",
+			attachVolumes: "datasets:/data, 12345:/home/daytona/monorepo/ ,cache",
+			want: []VolumeAttachment{
+				{Volume: "datasets", MountPath: "/data"},
+				{Volume: "12345", MountPath: "/home/daytona/monorepo"},
+				{Volume: "cache", MountPath: "/mnt/cache"},
+			},
+		},
+		{
+			name:          "relative mount path",
+			attachVolumes: "datasets:data",
+			wantErr:       true,
+		},
+		{
+			name:          "mount path with shell characters",
+			attachVolumes: "datasets:/data;reboot",
+			wantErr:       true,
+		},
+		{
+			name:          "root mount path",
+			attachVolumes: "datasets:/",
+			wantErr:       true,
+		},
+		{
+			name:          "duplicate volume",
+			attachVolumes: "datasets:/a,datasets:/b",
+			wantErr:       true,
+		},
+		{
+			name:          "duplicate mount path",
+			attachVolumes: "datasets:/data,cache:/data",
+			wantErr:       true,
+		},
+		{
+			name:          "missing volume",
+			attachVolumes: ":/data",
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &TargetOptions{AttachVolumes: tt.attachVolumes}
+			got, err := options.VolumeAttachments()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VolumeAttachments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VolumeAttachments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
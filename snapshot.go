@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	hetznerutil "github.com/daytonaio/daytona-provider-hetzner/pkg/provider/util"
+	"github.com/daytonaio/daytona-provider-hetzner/pkg/types"
+	"github.com/daytonaio/daytona/pkg/workspace"
+)
+
+// runSnapshot takes an on-demand snapshot of a workspace server.
+// Usage: daytona-provider-hetzner snapshot -workspace-id <id> [-target-options '{...}']
+func runSnapshot(args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	workspaceId := flags.String("workspace-id", "", "Id of the workspace to snapshot")
+	targetOptionsJson := flags.String("target-options", "{}", "Target options JSON used to authenticate with Hetzner")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *workspaceId == "" {
+		return errors.New("-workspace-id is required")
+	}
+
+	targetOptions, err := types.ParseTargetOptions(*targetOptionsJson)
+	if err != nil {
+		return err
+	}
+	for _, warning := range targetOptions.Warnings {
+		fmt.Fprintln(os.Stderr, "Warning: "+warning)
+	}
+
+	snapshot, err := hetznerutil.CreateSnapshot(&workspace.Workspace{Id: *workspaceId}, targetOptions, types.SnapshotReasonOnDemand, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(snapshot.ID)
+	return nil
+}